@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/shusako/go_snake_neural_network/network"
+	"github.com/shusako/go_snake_neural_network/snakegame/snake"
+)
+
+// evaluateArena plays the networks against each other in one arena game and scores each of them
+func (g *EvolutionManager) evaluateArena(feedForwards []network.FeedForward) []float64 {
+	inputs := make([]snake.Input, len(feedForwards))
+	for i, feedForward := range feedForwards {
+		inputs[i] = NewNeuralInput(feedForward, g.evaluationPolicy)
+	}
+
+	arena, err := snake.NewArena(g.gameConfig, inputs)
+	if err != nil {
+		panic(err)
+	}
+
+	snake.NewRunner(arena, &snake.FastClock{}).Run()
+
+	return GetArenaFitness(arena)
+}
+
+// GetArenaFitness scores every snake in a finished arena game, placement matters most, then apples, then how long it survived
+func GetArenaFitness(arena *snake.Arena) []float64 {
+	placements := arena.Placements()
+	fitness := make([]float64, len(arena.Snakes))
+
+	for i, player := range arena.Snakes {
+		survived := arena.DiedAt[i]
+		if survived == 0 {
+			survived = arena.Game.Moves
+		}
+
+		beaten := float64(len(arena.Snakes) - placements[i])
+		fitness[i] = beaten*1000 + countApples(player)*100 + float64(survived)
+	}
+
+	return fitness
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math"
+
+	"github.com/shusako/go_snake_neural_network/network"
+	"github.com/shusako/go_snake_neural_network/snakegame/snake"
+)
+
+// layout of the behavior vector GetBehavior returns
+const (
+	BehaviorHeadX = iota
+	BehaviorHeadY
+	// apples eaten as a share of the board
+	BehaviorApples
+	// share of the board's squares the head has been on
+	BehaviorCoverage
+	// share of the moves spent in each region of a 3x3 split of the board, left to right then top to bottom
+	BehaviorRegions
+	// 1 for the cause of death and 0 for the others, one entry per snake.DeathCause
+	BehaviorDeathCause = BehaviorRegions + 9
+	BehaviorSize       = BehaviorDeathCause + int(snake.HeadOn) + 1
+)
+
+// GetBehavior describes how a finished game was played, for novelty search and MAP-Elites, every entry is between 0 and 1
+func GetBehavior(game *snake.Game) []float64 {
+	behavior := make([]float64, BehaviorSize)
+
+	// a snake that hit the wall has its head just off the board, it counts as being on the edge it crossed
+	behavior[BehaviorHeadX] = math.Max(0, math.Min(1, float64(game.Snake.Head.X)/(snake.BoardWidth-1)))
+	behavior[BehaviorHeadY] = math.Max(0, math.Min(1, float64(game.Snake.Head.Y)/(snake.BoardHeight-1)))
+	behavior[BehaviorApples] = countApples(&game.Snake) / (snake.BoardWidth * snake.BoardHeight)
+
+	visited, moves := 0, 0
+	for y, row := range game.Visits {
+		for x, visits := range row {
+			if visits > 0 {
+				visited++
+			}
+			moves += visits
+			behavior[BehaviorRegions+y*3/snake.BoardHeight*3+x*3/snake.BoardWidth] += float64(visits)
+		}
+	}
+	behavior[BehaviorCoverage] = float64(visited) / (snake.BoardWidth * snake.BoardHeight)
+	if moves > 0 {
+		for region := 0; region < 9; region++ {
+			behavior[BehaviorRegions+region] /= float64(moves)
+		}
+	}
+
+	behavior[BehaviorDeathCause+int(game.Snake.DeathCause)] = 1
+
+	return behavior
+}
+
+// mapElitesDimensions lays the MAP-Elites grid out by how much of the board the snake covers and how much it eats
+var mapElitesDimensions = []network.BehaviorDimension{
+	{Index: BehaviorCoverage, Min: 0, Max: 1, Bins: 20},
+	{Index: BehaviorApples, Min: 0, Max: 0.5, Bins: 20},
+}
+
+// evaluateBehavior is evaluateGame that also describes how the snake played
+func (manager *EvolutionManager) evaluateBehavior(feedForward network.FeedForward) (float64, []float64) {
+	game := manager.playGame(feedForward)
+
+	return GetFitness(game), GetBehavior(game)
+}
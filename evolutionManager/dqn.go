@@ -0,0 +1,341 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/shusako/go_snake_neural_network/network"
+	"github.com/shusako/go_snake_neural_network/snakegame/snake"
+)
+
+// DQNConfig holds the settings of a DQNAgent, start from DefaultDQNConfig
+type DQNConfig struct {
+	// games played per Step, what a generation is to the evolutionary algorithms
+	EpisodesPerStep int
+	// moves kept to learn from, the oldest are dropped first
+	ReplaySize int
+	// moves sampled from the replay buffer for each learning step
+	BatchSize int
+	// learning starts once the replay buffer holds this many moves
+	WarmupMoves int
+	// moves played between learning steps
+	TrainEvery int
+	// moves played between copies of the trained network into the target network
+	TargetUpdate int
+	// discount of future rewards
+	Gamma        float64
+	LearningRate float64
+	// chance of a random move, falls from EpsilonStart to EpsilonEnd over EpsilonDecayMoves
+	EpsilonStart      float64
+	EpsilonEnd        float64
+	EpsilonDecayMoves int
+
+	// reward for each apple eaten, weighted by foodWeights, and penalties for dying and for every move
+	AppleReward  float64
+	DeathPenalty float64
+	StepPenalty  float64
+}
+
+func DefaultDQNConfig() DQNConfig {
+	return DQNConfig{
+		EpisodesPerStep:   20,
+		ReplaySize:        100000,
+		BatchSize:         64,
+		WarmupMoves:       1000,
+		TrainEvery:        4,
+		TargetUpdate:      2000,
+		Gamma:             0.95,
+		LearningRate:      1e-4,
+		EpsilonStart:      1,
+		EpsilonEnd:        0.05,
+		EpsilonDecayMoves: 200000,
+		AppleReward:       10,
+		DeathPenalty:      10,
+		StepPenalty:       0.01,
+	}
+}
+
+// transition is one move of a training game, next is nil when the move ended the game
+type transition struct {
+	state  []float64
+	action int
+	reward float64
+	next   []float64
+}
+
+// DQNAgent trains a single network to estimate how much reward each move leads to with deep Q-learning, learning
+// from random samples of the moves of its own games. It implements network.Optimizer so the training loop and the
+// viewer work the same as with the evolutionary algorithms.
+type DQNAgent struct {
+	config     DQNConfig
+	gameConfig snake.Config
+
+	online    *network.Network
+	backprop  *network.Backprop
+	optimizer network.GradientOptimizer
+	// copy of the online network the targets are worked out with, only updated every TargetUpdate moves so the
+	// network isn't chasing its own changes
+	target            *network.Network
+	targetFeedForward network.FeedForward
+
+	// ring buffer of moves to learn from
+	replay     []transition
+	replayNext int
+
+	policy *EpsilonGreedyPolicy
+
+	generationNumber int
+	evaluated        bool
+	moves            int
+	episodes         int
+
+	// best fitness of the last step's games and the average loss of its learning steps
+	bestFitness float64
+	lossSum     float64
+	lossCount   int
+
+	// called with every training game, for the evaluation stats
+	record func(game *snake.Game, duration time.Duration)
+}
+
+func NewDQNAgent(spec network.Spec, config DQNConfig, gameConfig snake.Config) *DQNAgent {
+	if spec.Sizes[0] != EncodingSize || spec.Sizes[len(spec.Sizes)-1] != 4 {
+		panic(fmt.Sprintf("DQN needs %d inputs and one output per direction", EncodingSize))
+	}
+
+	agent := &DQNAgent{
+		config:     config,
+		gameConfig: gameConfig,
+		online:     network.NewNetwork(spec),
+		optimizer:  network.NewAdam(config.LearningRate),
+		policy:     &EpsilonGreedyPolicy{Epsilon: config.EpsilonStart, Policy: &ArgmaxPolicy{}},
+	}
+	// the random moves can get the snake out of a repeated state, MaxMoves still ends games that go on too long
+	agent.gameConfig.DetectLoops = false
+	agent.backprop = agent.online.NewBackprop()
+	agent.syncTarget()
+
+	return agent
+}
+
+func (agent *DQNAgent) syncTarget() {
+	agent.target = agent.online.Clone()
+	agent.targetFeedForward = agent.target.FeedForward()
+}
+
+func (agent *DQNAgent) epsilon() float64 {
+	progress := math.Min(1, float64(agent.moves)/float64(agent.config.EpsilonDecayMoves))
+
+	return agent.config.EpsilonStart + (agent.config.EpsilonEnd-agent.config.EpsilonStart)*progress
+}
+
+// dqnInput picks the moves of the agent's training games and remembers the state and move it picked
+type dqnInput struct {
+	agent  *DQNAgent
+	state  []float64
+	action int
+}
+
+func (input *dqnInput) Init() {}
+
+func (input *dqnInput) Reset() {}
+
+func (input *dqnInput) Poll() {}
+
+func (input *dqnInput) HandleInput(game *snake.Game, player *snake.Snake) {
+	input.state = EncodeGameBoard(game, player)
+	input.action = input.agent.policy.SelectAction(game, player, input.agent.online.Forward(input.state))
+
+	player.TargetDirection = input.action
+}
+
+// reward shapes what happened in the last move into a reward, given how much the snake had eaten before it
+func (agent *DQNAgent) reward(game *snake.Game, eaten [snake.FoodTypeCount]int) float64 {
+	reward := -agent.config.StepPenalty
+	for foodType, count := range game.Snake.Eaten {
+		reward += agent.config.AppleReward * foodWeights[foodType] * float64(count-eaten[foodType])
+	}
+
+	if game.Snake.IsDead && game.Snake.DeathCause != snake.MoveCapped {
+		reward -= agent.config.DeathPenalty
+	}
+
+	return reward
+}
+
+// playEpisode plays one training game, learning as it goes
+func (agent *DQNAgent) playEpisode() *snake.Game {
+	start := time.Now()
+
+	input := &dqnInput{agent: agent}
+	game := &snake.Game{}
+	game.Config = agent.gameConfig
+	game.Reset()
+	game.Input = input
+
+	for !game.IsOver {
+		eaten := game.Snake.Eaten
+		agent.policy.Epsilon = agent.epsilon()
+		game.Step()
+
+		move := transition{state: input.state, action: input.action, reward: agent.reward(game, eaten)}
+		// running out of moves isn't the snake's doing, the game was only cut short so what comes next still counts
+		if !game.IsOver || game.Snake.DeathCause == snake.MoveCapped {
+			move.next = EncodeGameBoard(game, &game.Snake)
+		}
+		agent.remember(move)
+
+		agent.moves++
+		if len(agent.replay) >= agent.config.WarmupMoves && agent.moves%agent.config.TrainEvery == 0 {
+			agent.learn()
+		}
+		if agent.moves%agent.config.TargetUpdate == 0 {
+			agent.syncTarget()
+		}
+	}
+
+	agent.episodes++
+	if agent.record != nil {
+		agent.record(game, time.Since(start))
+	}
+
+	return game
+}
+
+func (agent *DQNAgent) remember(move transition) {
+	if len(agent.replay) < agent.config.ReplaySize {
+		agent.replay = append(agent.replay, move)
+		return
+	}
+
+	agent.replay[agent.replayNext] = move
+	agent.replayNext = (agent.replayNext + 1) % agent.config.ReplaySize
+}
+
+// learn takes one gradient descent step on a random batch of remembered moves, moving the estimate of the move
+// that was made towards its reward plus the discounted value of the best move after it
+func (agent *DQNAgent) learn() {
+	outputGradient := make([]float64, 4)
+
+	for i := 0; i < agent.config.BatchSize; i++ {
+		move := agent.replay[rand.Intn(len(agent.replay))]
+
+		target := move.reward
+		if move.next != nil {
+			next := agent.targetFeedForward(move.next)
+			target += agent.config.Gamma * next[argmax(next, nil)]
+		}
+
+		outputs := agent.backprop.Forward(move.state)
+
+		// only the estimate of the move that was made is trained, the Huber loss keeps a few big surprises from
+		// blowing up a step
+		for j := range outputGradient {
+			outputGradient[j] = 0
+		}
+		action := move.action
+		agent.lossSum += network.Huber.Evaluate(outputs[action:action+1], []float64{target}, outputGradient[action:action+1])
+		agent.lossCount++
+
+		outputGradient[action] /= float64(agent.config.BatchSize)
+		agent.backprop.Backward(outputGradient)
+	}
+
+	agent.optimizer.Step(agent.online, agent.backprop.Gradient())
+	agent.backprop.ZeroGradient()
+}
+
+// Step plays the next EpisodesPerStep training games
+func (agent *DQNAgent) Step() {
+	if agent.evaluated {
+		agent.generationNumber++
+	}
+
+	agent.bestFitness = math.Inf(-1)
+	agent.lossSum, agent.lossCount = 0, 0
+	for i := 0; i < agent.config.EpisodesPerStep; i++ {
+		agent.bestFitness = math.Max(agent.bestFitness, GetFitness(agent.playEpisode()))
+	}
+
+	agent.evaluated = true
+}
+
+// Best is a copy of the network being trained, so it can be watched while training goes on
+func (agent *DQNAgent) Best() network.FeedForward {
+	return agent.online.Clone().FeedForward()
+}
+
+// Stats has the best fitness of the last step's games, which include random moves
+func (agent *DQNAgent) Stats() network.Stats {
+	loss := 0.0
+	if agent.lossCount > 0 {
+		loss = agent.lossSum / float64(agent.lossCount)
+	}
+
+	return network.Stats{
+		Generation:  agent.generationNumber,
+		BestFitness: agent.bestFitness,
+		Details: []network.Detail{
+			{Name: "Epsilon", Value: fmt.Sprintf("%.3f", agent.epsilon())},
+			{Name: "Episodes", Value: fmt.Sprint(agent.episodes)},
+			{Name: "Loss", Value: fmt.Sprintf("%.4f", loss)},
+		},
+	}
+}
+
+// SaveBest writes the network being trained to path as JSON
+func (agent *DQNAgent) SaveBest(path string) error {
+	return agent.online.Save(path)
+}
+
+// dqnCheckpoint is the state of a DQNAgent in a checkpoint, the replay buffer and Adam's averages aren't kept,
+// learning starts again once the buffer has refilled past WarmupMoves
+type dqnCheckpoint struct {
+	Generation  int       `json:"generation"`
+	Evaluated   bool      `json:"evaluated"`
+	Moves       int       `json:"moves"`
+	Episodes    int       `json:"episodes"`
+	BestFitness float64   `json:"bestFitness"`
+	Online      []float64 `json:"online"`
+	Target      []float64 `json:"target"`
+}
+
+// Checkpoint writes both networks and how far training has got to w as JSON
+func (agent *DQNAgent) Checkpoint(w io.Writer) error {
+	return json.NewEncoder(w).Encode(dqnCheckpoint{
+		Generation:  agent.generationNumber,
+		Evaluated:   agent.evaluated,
+		Moves:       agent.moves,
+		Episodes:    agent.episodes,
+		BestFitness: agent.bestFitness,
+		Online:      agent.online.Genome(),
+		Target:      agent.target.Genome(),
+	})
+}
+
+// Restore continues from a checkpoint written by Checkpoint of an agent with the same spec
+func (agent *DQNAgent) Restore(r io.Reader) error {
+	var checkpoint dqnCheckpoint
+	if err := json.NewDecoder(r).Decode(&checkpoint); err != nil {
+		return err
+	}
+
+	if err := agent.online.SetGenome(checkpoint.Online); err != nil {
+		return err
+	}
+	if err := agent.target.SetGenome(checkpoint.Target); err != nil {
+		return err
+	}
+
+	agent.generationNumber = checkpoint.Generation
+	agent.evaluated = checkpoint.Evaluated
+	agent.moves = checkpoint.Moves
+	agent.episodes = checkpoint.Episodes
+	agent.bestFitness = checkpoint.BestFitness
+
+	return nil
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"image"
 	"image/color"
+	"log"
 	"math"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -25,44 +31,318 @@ type EvolutionManager struct {
 	nextGame      *snake.Game
 	nextGameMutex *sync.Mutex
 
-	nextTick time.Time
-	TickMs   int64
+	// paces the game being watched, training games always run as fast as possible
+	runner *snake.Runner
 
+	// algorithm the training loop runs, the genetic algorithm or its islands unless another one is picked
+	optimizer        network.Optimizer
 	geneticAlgorithm *network.GeneticAlgorithm
+	islands          *network.IslandModel
+
+	// archive of the MAP-Elites run drawn next to the game, updated by the training loop every generation
+	heatmap     *ebiten.Image
+	nextHeatmap image.Image
+
+	// policies used to pick moves from the network outputs while training and while watching the best snake, the
+	// name of the training policy is saved next to the best network and the checkpoint
+	evaluationPolicy ActionPolicy
+	viewerPolicy     ActionPolicy
+	policyName       string
+
+	// rules the training games are played with
+	gameConfig snake.Config
+	stats      evaluationStats
+
+	// settings the genetic algorithm is made with, UseIslands splits them between the islands
+	populationSize int
+	spec           network.Spec
+	mutationChance float64
+	mutationRate   float64
+}
+
+// evaluationStats tracks how many training games were cut short and roughly how much time that saved
+type evaluationStats struct {
+	games          atomic.Int64
+	looped         atomic.Int64
+	capped         atomic.Int64
+	moves          atomic.Int64
+	movesSaved     atomic.Int64
+	evaluationTime atomic.Int64
+}
+
+func (stats *evaluationStats) record(game *snake.Game, duration time.Duration) {
+	stats.games.Add(1)
+	stats.moves.Add(int64(game.Moves))
+	stats.evaluationTime.Add(int64(duration))
+
+	switch game.Snake.DeathCause {
+	case snake.Looped:
+		// a looping snake would have kept going until it starved
+		stats.looped.Add(1)
+		stats.movesSaved.Add(int64(snake.MaxMovesSinceFood + 1 - game.Snake.MovesSinceFood))
+	case snake.MoveCapped:
+		stats.capped.Add(1)
+	}
+}
+
+// report prints the stats since the last report and resets them
+func (stats *evaluationStats) report() {
+	games := stats.games.Swap(0)
+	looped := stats.looped.Swap(0)
+	capped := stats.capped.Swap(0)
+	moves := stats.moves.Swap(0)
+	movesSaved := stats.movesSaved.Swap(0)
+	evaluationTime := stats.evaluationTime.Swap(0)
+
+	timeSaved := time.Duration(0)
+	if moves > 0 {
+		timeSaved = time.Duration(float64(evaluationTime) / float64(moves) * float64(movesSaved))
+	}
+
+	fmt.Printf(", Games: %d, Looped: %d, Capped: %d, Moves saved: %d (~%s)\n", games, looped, capped, movesSaved, timeSaved)
 }
 
 func NewEvolutionManager() *EvolutionManager {
-	manager := &EvolutionManager{}
+	manager := &EvolutionManager{
+		evaluationPolicy: &ArgmaxPolicy{},
+		viewerPolicy:     &ArgmaxPolicy{},
+		policyName:       "argmax",
+		gameConfig: snake.Config{
+			DetectLoops: true,
+			MaxMoves:    5000,
+			Wrap:        false,
+		},
+	}
 
 	populationSize := 1300
-	// sizes := []int{120, 18, 18, 4}
-	sizes := []int{3*8 + 20, 18, 18, 4}
+	// spec := network.DefaultSpec(120, 18, 18, 4)
+	spec := network.DefaultSpec(EncodingSize, 18, 18, 4)
+	// tanh hidden layers and softmax outputs, the policies pick from the output like probabilities
+	// spec.Activations = []network.Activation{{Function: network.Tanh}, {Function: network.Tanh}, {Function: network.Softmax}}
+	// start the weights scaled to the size of each layer instead of from [-1, 1]
+	// spec.WeightInit, spec.BiasInit = network.He, network.Zeros
 	// TODO: Lookup what these values actually translate to in production algorithms so we match
 	mutationChance := 0.01
 	mutationRate := 0.1
 
-	ga := network.NewGeneticAlgoritm(populationSize, sizes, mutationChance, mutationRate, func(feedForward network.FeedForward) float64 {
-		game := &snake.Game{}
-		game.Reset()
-		game.Input = NewNeuralInput(feedForward)
-		game.TickMs = -1
-		for !game.IsOver {
-			game.Update()
-		}
+	ga := network.NewGeneticAlgoritm(populationSize, spec, mutationChance, mutationRate, manager.evaluateGame)
+
+	// split the population into species that share their fitness, keeping around 15 of them
+	// ga.UseNiching(network.Niching{Threshold: 0.5, TargetSpecies: 15})
 
-		return GetFitness(game)
-	})
+	// run the networks in float32, faster and close enough for picking moves
+	// ga.SetPrecision(network.Float32)
 
 	manager.geneticAlgorithm = ga
+	manager.optimizer = ga
+	manager.populationSize = populationSize
+	manager.spec = spec
+	manager.mutationChance = mutationChance
+	manager.mutationRate = mutationRate
+
 	manager.nextGameMutex = &sync.Mutex{}
+	manager.runner = snake.NewRunner(nil, &snake.RealTimeClock{Interval: 100 * time.Millisecond})
 
 	return manager
 }
 
+// UsePolicy picks moves from the network outputs with the named policy, see ParsePolicy, both while training and
+// while watching the best snake so it plays the way it was trained. Loop detection is only left on for policies
+// that always pick the same move in the same state.
+func (manager *EvolutionManager) UsePolicy(name string) error {
+	policy, err := ParsePolicy(name)
+	if err != nil {
+		return err
+	}
+
+	manager.evaluationPolicy = policy
+	manager.viewerPolicy = policy
+	manager.policyName = name
+	manager.gameConfig.DetectLoops = policy.Deterministic()
+
+	return nil
+}
+
+// UseNEAT trains with NEAT instead of the fixed topology genetic algorithm, starting from inputs wired straight to outputs
+func (manager *EvolutionManager) UseNEAT(populationSize int) {
+	config := network.DefaultNEATConfig(EncodingSize, 4)
+	manager.optimizer = network.NewNEAT(populationSize, config, manager.evaluateGame)
+}
+
+// UseNovelty selects individuals for how differently they play from each other and from the archive instead of for
+// their fitness, for the genetic algorithm or every island, whichever is training
+func (manager *EvolutionManager) UseNovelty() error {
+	novelty := network.Novelty{Neighbors: 15, ArchiveThreshold: 0.5, ArchiveChance: 0.001}
+
+	switch optimizer := manager.optimizer.(type) {
+	case *network.GeneticAlgorithm:
+		optimizer.UseNovelty(novelty, manager.evaluateBehavior)
+	case *network.IslandModel:
+		for _, island := range optimizer.Islands() {
+			island.UseNovelty(novelty, manager.evaluateBehavior)
+		}
+	default:
+		return errors.New("novelty search only works with the genetic algorithm")
+	}
+
+	return nil
+}
+
+// UseIslands splits the genetic algorithm's population between count islands, ranging from half to double the
+// mutation rate, which trade their best migrants every interval generations along the topology
+func (manager *EvolutionManager) UseIslands(count int, topology network.MigrationTopology, interval, migrants int) error {
+	if count < 2 {
+		return fmt.Errorf("an island model needs at least 2 islands, not %d", count)
+	}
+
+	islands := make([]*network.GeneticAlgorithm, count)
+	for i := range islands {
+		rate := manager.mutationRate * math.Pow(2, 2*float64(i)/float64(count-1)-1)
+		islands[i] = network.NewGeneticAlgoritm(manager.populationSize/count, manager.spec, manager.mutationChance, rate, manager.evaluateGame)
+	}
+
+	model, err := network.NewIslandModel(islands, topology, interval, migrants)
+	if err != nil {
+		return err
+	}
+	manager.islands = model
+	manager.optimizer = model
+
+	return nil
+}
+
+// UseArena co-evolves the genetic algorithm, or every island, by playing snakes against each other in arena games
+// of the given size instead of alone, pairing them up for the given number of rounds
+func (manager *EvolutionManager) UseArena(snakes int, pairing network.Pairing, rounds int) error {
+	if snakes < snake.MinArenaSnakes || snakes > snake.MaxArenaSnakes {
+		return fmt.Errorf("arena games need %d to %d snakes, not %d", snake.MinArenaSnakes, snake.MaxArenaSnakes, snakes)
+	}
+	if rounds < 1 {
+		return errors.New("arena games need at least 1 round")
+	}
+
+	// the starts of the arena have to be free on the training level
+	inputs := make([]snake.Input, snakes)
+	for i := range inputs {
+		inputs[i] = NewNeuralInput(nil, manager.evaluationPolicy)
+	}
+	if _, err := snake.NewArena(manager.gameConfig, inputs); err != nil {
+		return err
+	}
+
+	switch optimizer := manager.optimizer.(type) {
+	case *network.GeneticAlgorithm:
+		optimizer.UseGroupEvaluation(pairing, snakes, rounds, manager.evaluateArena)
+	case *network.IslandModel:
+		for _, island := range optimizer.Islands() {
+			island.UseGroupEvaluation(pairing, snakes, rounds, manager.evaluateArena)
+		}
+	default:
+		return errors.New("arena games only work with the genetic algorithm")
+	}
+
+	return nil
+}
+
+// UseMAPElites trains by keeping the best network for every mix of board coverage and apples eaten
+func (manager *EvolutionManager) UseMAPElites(batchSize int, spec network.Spec) {
+	manager.optimizer = network.NewMAPElites(batchSize, spec, mapElitesDimensions, 0.05, 0.3, manager.evaluateBehavior)
+}
+
+// UseEvolutionStrategy trains a single network by following the fitness gradient estimated from random perturbations
+func (manager *EvolutionManager) UseEvolutionStrategy(spec network.Spec) {
+	manager.optimizer = network.NewEvolutionStrategy(spec, network.DefaultEvolutionStrategyConfig(), manager.evaluateGame)
+}
+
+// UseCMAES trains a compact network with CMA-ES, which adapts a full covariance matrix over every parameter
+func (manager *EvolutionManager) UseCMAES(spec network.Spec) {
+	manager.optimizer = network.NewCMAES(spec, network.DefaultCMAESConfig(), manager.evaluateGame)
+}
+
+// UseDifferentialEvolution trains with differential evolution using the given strategy for building mutants
+func (manager *EvolutionManager) UseDifferentialEvolution(populationSize int, spec network.Spec, strategy network.DEStrategy) {
+	manager.optimizer = network.NewDifferentialEvolution(populationSize, spec, strategy, 0.5, 0.9, manager.evaluateGame)
+}
+
+// UseDQN trains a single network with deep Q-learning from the moves of its own games instead of evolving one
+func (manager *EvolutionManager) UseDQN(spec network.Spec) {
+	agent := NewDQNAgent(spec, DefaultDQNConfig(), manager.gameConfig)
+	agent.record = manager.stats.record
+	manager.optimizer = agent
+}
+
+// saveCheckpoint writes the optimizer's checkpoint next to path first and then moves it over, so stopping training
+// while it is being written doesn't lose the last one
+func (manager *EvolutionManager) saveCheckpoint(path string) error {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	if err := manager.optimizer.Checkpoint(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := SavePolicy(path, manager.policyName); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// loadCheckpoint continues training from the checkpoint at path, it has to come from the same algorithm and settings
+func (manager *EvolutionManager) loadCheckpoint(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		return err
+	}
+	if policy != manager.policyName {
+		return fmt.Errorf("checkpoint %s was trained with policy %s, not %s", path, policy, manager.policyName)
+	}
+
+	return manager.optimizer.Restore(file)
+}
+
+// evaluateGame plays one training game with the network and scores it
+func (manager *EvolutionManager) evaluateGame(feedForward network.FeedForward) float64 {
+	return GetFitness(manager.playGame(feedForward))
+}
+
+// playGame plays one training game with the network and records it in the stats
+func (manager *EvolutionManager) playGame(feedForward network.FeedForward) *snake.Game {
+	start := time.Now()
+
+	game := &snake.Game{}
+	game.Config = manager.gameConfig
+	game.Reset()
+	game.Input = NewNeuralInput(feedForward, manager.evaluationPolicy)
+	snake.NewRunner(game, &snake.FastClock{}).Run()
+
+	manager.stats.record(game, time.Since(start))
+
+	return game
+}
+
+// foodWeights is how many apples eating each type of food counts as in the fitness
+var foodWeights = [snake.FoodTypeCount]float64{
+	snake.NormalFood: 1,
+	snake.BonusFood:  3,
+	snake.PoisonFood: -1,
+}
+
 func GetFitness(game *snake.Game) float64 {
 	// fitness should be based on snake length minus number of moves
 
-	apples := float64(len(game.Snake.Tail) - 3)
+	apples := countApples(&game.Snake)
 	steps := float64(game.Moves)
 
 	return steps + (math.Pow(2, apples) + math.Pow(apples, 2.1)*500) - (math.Pow(apples, 1.2) * math.Pow(0.25*steps, 1.3))
@@ -70,6 +350,16 @@ func GetFitness(game *snake.Game) float64 {
 	//return math.Pow(1.5, float64(len(game.Snake.Tail))) - (math.Max(0, float64(game.Moves-10)) / 10)
 }
 
+// countApples weighs the food the snake ate into a number of apples, never less than 0
+func countApples(player *snake.Snake) float64 {
+	apples := 0.0
+	for foodType, eaten := range player.Eaten {
+		apples += foodWeights[foodType] * float64(eaten)
+	}
+
+	return math.Max(0, apples)
+}
+
 func DrawSquare(mainImage *ebiten.Image, x, y int, color color.RGBA) {
 	vector.DrawFilledRect(mainImage, float32(x), float32(y), 1, 1, color, false)
 }
@@ -80,21 +370,39 @@ func (g *EvolutionManager) Draw(screen *ebiten.Image) {
 	if g.game != nil {
 		DrawSnakeGame(g.game, screen)
 		text.Draw(screen, fmt.Sprintf("Fitness: %f", GetFitness(g.game)), basicfont.Face7x13, 2, 12, color.White)
+		text.Draw(screen, fmt.Sprintf("Policy: %s", g.viewerPolicy), basicfont.Face7x13, 2, 26, color.White)
+	}
+
+	if g.heatmap != nil {
+		// scaled to fit the left half of the screen below the text
+		padding := 10
+		op := &ebiten.DrawImageOptions{}
+		scale := math.Min(float64(ScreenWidth/2-2*padding)/float64(g.heatmap.Bounds().Dx()), float64(ScreenHeight-40-padding)/float64(g.heatmap.Bounds().Dy()))
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(float64(padding), 40)
+		screen.DrawImage(g.heatmap, op)
+		text.Draw(screen, "MAP-Elites: coverage left to right, apples bottom to top", basicfont.Face7x13, padding, 36, color.White)
 	}
 }
 
 func DrawSnakeGame(snakegame *snake.Game, screen *ebiten.Image) {
-	// draw the game on the right side of the screen
-	snakeGameImage := ebiten.NewImage(snakegame.Layout(0, 0))
-	snakegame.Draw(snakeGameImage)
-
 	// draw snakeGameImage on right side of screen at 650, 10 to 1270, 710
-	op := &ebiten.DrawImageOptions{}
 	padding := 10
-	topLeftX := ScreenWidth/2 + padding
-	topLeftY := padding
-	bottomRightX := ScreenWidth - padding
-	bottomRightY := ScreenHeight - padding
+	DrawInRegion(screen, snakegame, ScreenWidth/2+padding, padding, ScreenWidth-padding, ScreenHeight-padding)
+}
+
+// drawable is anything that can draw itself at its own layout size, like snake.Game and snake.Arena
+type drawable interface {
+	Draw(screen *ebiten.Image)
+	Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int)
+}
+
+// DrawInRegion draws the game scaled to fit inside the region of the screen, keeping its aspect ratio
+func DrawInRegion(screen *ebiten.Image, game drawable, topLeftX, topLeftY, bottomRightX, bottomRightY int) {
+	snakeGameImage := ebiten.NewImage(game.Layout(0, 0))
+	game.Draw(snakeGameImage)
+
+	op := &ebiten.DrawImageOptions{}
 	sideScale := math.Min(float64(bottomRightX-topLeftX)/float64(snakeGameImage.Bounds().Dx()), float64(bottomRightY-topLeftY)/float64(snakeGameImage.Bounds().Dy()))
 	op.GeoM.Scale(sideScale, sideScale)
 	op.GeoM.Translate(float64(topLeftX), float64(topLeftY))
@@ -103,20 +411,21 @@ func DrawSnakeGame(snakegame *snake.Game, screen *ebiten.Image) {
 
 func (g *EvolutionManager) Update() error {
 	g.nextGameMutex.Lock()
+	if g.nextHeatmap != nil {
+		g.heatmap = ebiten.NewImageFromImage(g.nextHeatmap)
+		g.nextHeatmap = nil
+	}
 	if g.nextGame != nil {
 		if (g.game == nil) || (g.game.IsOver) {
 			g.game = g.nextGame
+			g.runner.Simulation = g.game
 			g.nextGame = nil
 		}
 	}
 	g.nextGameMutex.Unlock()
 
 	if g.game != nil {
-		// check if current time is past next tick
-		if time.Now().After(g.nextTick) {
-			g.game.Update() // ignoring error
-			g.nextTick = time.Now().Add(time.Duration(g.TickMs) * time.Millisecond)
-		}
+		return g.runner.Update()
 	}
 
 	return nil
@@ -126,41 +435,245 @@ func (g *EvolutionManager) Layout(outsideWidth, outsideHeight int) (screenWidth,
 	return 1280, 720
 }
 
-func CreateGameFromFeedForward(feedForward network.FeedForward) *snake.Game {
+// viewerConfig plays by the same rules as training but lets the game run to its natural end
+func (g *EvolutionManager) viewerConfig() snake.Config {
+	config := g.gameConfig
+	config.DetectLoops = false
+	config.MaxMoves = 0
+
+	return config
+}
+
+func CreateGameFromFeedForward(feedForward network.FeedForward, policy ActionPolicy, config snake.Config) *snake.Game {
 	game := &snake.Game{}
+	game.Config = config
 	game.Reset()
-	game.Input = NewNeuralInput(feedForward)
-	game.TickMs = -1
-	//game.AutoRestart = true
+	game.Input = NewNeuralInput(feedForward, policy)
 
 	return game
 }
 
 func main() {
+	mode := flag.String("mode", "train", "train, versus (you and the AI on one board) or split (you and the AI side by side on the same seed)")
+	networkPath := flag.String("network", "", "saved network for the AI to play with in versus and split mode")
+	policyName := flag.String("policy", "", "how moves are picked from the network outputs: argmax, legal-argmax (never into a wall or a snake), softmax=<temperature> or epsilon-greedy=<epsilon>, defaults to argmax when training and to the policy the network was trained with in versus and split mode")
+	savePath := flag.String("save", "", "file to save the best network to after every generation while training")
+	neat := flag.Bool("neat", false, "train with NEAT, evolving the topology of the networks along with their weights")
+	es := flag.Bool("es", false, "train a single network with evolution strategies instead of a population")
+	cmaes := flag.Bool("cmaes", false, "train a compact network with CMA-ES and BIPOP restarts")
+	de := flag.String("de", "", "train with differential evolution: rand1bin, best1bin or jade")
+	dqn := flag.Bool("dqn", false, "train a single network with deep Q-learning instead of evolution")
+	search := flag.String("search", "fitness", "fitness, novelty (select for unusual play) or map-elites (keep the best snake for every play style)")
+	levelPath := flag.String("level", "", "level file to train on instead of the empty board, like levels/pillars.txt")
+	islandCount := flag.Int("islands", 0, "split the genetic algorithm's population into this many islands with different mutation rates, 0 for one population")
+	topology := flag.String("topology", "ring", "where island migrants go: ring (the next island), full (every other island) or random")
+	migrationInterval := flag.Int("migration-interval", 10, "generations between island migrations")
+	migrants := flag.Int("migrants", 5, "best individuals each island sends every migration")
+	arenaSnakes := flag.Int("arena", 0, "co-evolve the genetic algorithm by playing this many snakes against each other in one arena game, 0 to train alone")
+	pairing := flag.String("pairing", "random", "who plays who in arena games: random, round-robin or hall-of-fame (against the best of earlier generations)")
+	arenaRounds := flag.Int("rounds", 3, "arena games every snake plays each generation")
+	wrap := flag.Bool("wrap", false, "train on a board whose edges wrap around instead of walls, the best snake is watched on the same board")
+	checkpointPath := flag.String("checkpoint", "", "file to save the training state to after every generation, training continues from it if it exists")
+	flag.Parse()
+
+	switch *mode {
+	case "train", "versus", "split":
+	default:
+		log.Fatalf("unknown -mode %q, use train, versus or split", *mode)
+	}
+
 	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
+
+	if *mode != "train" {
+		ebiten.SetWindowTitle("Snake Versus")
+
+		if *networkPath == "" {
+			log.Fatal("-network is needed to play against the AI")
+		}
+
+		if *policyName == "" {
+			recorded, err := LoadPolicy(*networkPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			*policyName = recorded
+		}
+		policy, err := ParsePolicy(*policyName)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		viewer, err := NewVersusViewer(*networkPath, policy, *mode == "split")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := ebiten.RunGame(viewer); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	ebiten.SetWindowTitle("Snake Evolution")
 
 	manager := NewEvolutionManager()
+	// set before the optimizers are made, DQN keeps its own copy of the rules
+	manager.gameConfig.Wrap = *wrap
+	if *levelPath != "" {
+		level, err := snake.LoadLevel(*levelPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		manager.gameConfig.Level = level
+	}
+	if *policyName != "" {
+		if err := manager.UsePolicy(*policyName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	// the optimizers replace the genetic algorithm, so only one can be picked and searching other than by fitness
+	// only applies to the genetic algorithm
+	optimizers := 0
+	for _, picked := range []bool{*neat, *es, *cmaes, *de != "", *dqn} {
+		if picked {
+			optimizers++
+		}
+	}
+	if optimizers > 1 {
+		log.Fatal("pick at most one of -neat, -es, -cmaes, -de and -dqn")
+	}
+	switch *search {
+	case "fitness":
+	case "novelty", "map-elites":
+		if optimizers > 0 {
+			log.Fatalf("-search %s trains with the genetic algorithm, it can't be combined with -neat, -es, -cmaes, -de or -dqn", *search)
+		}
+	default:
+		log.Fatalf("unknown -search %q, use fitness, novelty or map-elites", *search)
+	}
+	if *islandCount != 0 && (optimizers > 0 || *search == "map-elites") {
+		log.Fatal("-islands splits the genetic algorithm, it can't be combined with -neat, -es, -cmaes, -de, -dqn or -search map-elites")
+	}
+	var migrationTopology network.MigrationTopology
+	switch *topology {
+	case "ring":
+		migrationTopology = network.RingMigration
+	case "full":
+		migrationTopology = network.FullMigration
+	case "random":
+		migrationTopology = network.RandomMigration
+	default:
+		log.Fatalf("unknown -topology %q, use ring, full or random", *topology)
+	}
+	// islands first, the arena and novelty search are set up on every island
+	if *islandCount != 0 {
+		if err := manager.UseIslands(*islandCount, migrationTopology, *migrationInterval, *migrants); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *arenaSnakes != 0 && (optimizers > 0 || *search == "map-elites") {
+		log.Fatal("-arena co-evolves the genetic algorithm, it can't be combined with -neat, -es, -cmaes, -de, -dqn or -search map-elites")
+	}
+	var arenaPairing network.Pairing
+	switch *pairing {
+	case "random":
+		arenaPairing = network.RandomPairing
+	case "round-robin":
+		arenaPairing = network.RoundRobin
+	case "hall-of-fame":
+		arenaPairing = network.HallOfFamePairing
+	default:
+		log.Fatalf("unknown -pairing %q, use random, round-robin or hall-of-fame", *pairing)
+	}
+	if *arenaSnakes != 0 {
+		if err := manager.UseArena(*arenaSnakes, arenaPairing, *arenaRounds); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *neat {
+		manager.UseNEAT(150)
+	}
+	if *es {
+		manager.UseEvolutionStrategy(network.DefaultSpec(EncodingSize, 18, 18, 4))
+	}
+	if *cmaes {
+		// a few hundred parameters, the covariance matrix grows with the square of the count
+		manager.UseCMAES(network.DefaultSpec(EncodingSize, 6, 4))
+	}
+	switch *de {
+	case "rand1bin":
+		manager.UseDifferentialEvolution(200, network.DefaultSpec(EncodingSize, 18, 18, 4), network.DERand1Bin)
+	case "best1bin":
+		manager.UseDifferentialEvolution(200, network.DefaultSpec(EncodingSize, 18, 18, 4), network.DEBest1Bin)
+	case "jade":
+		manager.UseDifferentialEvolution(200, network.DefaultSpec(EncodingSize, 18, 18, 4), network.JADE)
+	case "":
+	default:
+		log.Fatalf("unknown -de %q, use rand1bin, best1bin or jade", *de)
+	}
+	if *dqn {
+		// estimates of future reward aren't bounded like a sigmoid's output
+		spec := network.DefaultSpec(EncodingSize, 64, 64, 4)
+		spec.Activations[len(spec.Activations)-1] = network.Activation{Function: network.Identity}
+		spec.WeightInit, spec.BiasInit = network.He, network.Zeros
+		manager.UseDQN(spec)
+	}
+	switch *search {
+	case "novelty":
+		if err := manager.UseNovelty(); err != nil {
+			log.Fatal(err)
+		}
+	case "map-elites":
+		manager.UseMAPElites(200, network.DefaultSpec(EncodingSize, 18, 18, 4))
+	}
+
+	if *checkpointPath != "" {
+		if err := manager.loadCheckpoint(*checkpointPath); err == nil {
+			fmt.Println("Continuing from", *checkpointPath)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Fatal(err)
+		}
+	}
 
 	// manager.game = &snake.Game{}
 	// manager.game.Reset()
 	// manager.game.Input = &snake.UserInput{}
-	// manager.game.TickMs = 100
-	// manager.TickMs = -1
+	// manager.game.Input.Init()
+	// manager.runner.Simulation = manager.game
 
-	manager.TickMs = 100
+	// step through the watched game with the period key instead of in real time
+	// manager.runner.Clock = &snake.ManualClock{Key: ebiten.KeyPeriod}
 
 	// blank goroutine with loop
 	go func() {
 		for {
-			manager.geneticAlgorithm.EvaluateGeneration()
-			nextGame := CreateGameFromFeedForward(manager.geneticAlgorithm.GetBestIndividual())
+			manager.optimizer.Step()
+			fmt.Print(manager.optimizer.Stats())
+			nextGame := CreateGameFromFeedForward(manager.optimizer.Best(), manager.viewerPolicy, manager.viewerConfig())
+			manager.stats.report()
+
+			if *savePath != "" {
+				if err := manager.optimizer.SaveBest(*savePath); err != nil {
+					log.Println(err)
+				}
+				if err := SavePolicy(*savePath, manager.policyName); err != nil {
+					log.Println(err)
+				}
+			}
+			if *checkpointPath != "" {
+				if err := manager.saveCheckpoint(*checkpointPath); err != nil {
+					log.Println(err)
+				}
+			}
 
 			manager.nextGameMutex.Lock()
 			manager.nextGame = nextGame
+			if mapElites, ok := manager.optimizer.(*network.MAPElites); ok {
+				manager.nextHeatmap = mapElites.Heatmap()
+			}
 			manager.nextGameMutex.Unlock()
-
-			manager.geneticAlgorithm.EvolveGeneration()
 		}
 	}()
 
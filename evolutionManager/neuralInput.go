@@ -7,76 +7,102 @@ import (
 	"github.com/shusako/go_snake_neural_network/snakegame/snake"
 )
 
+// EncodingSize is the number of inputs EncodeGameBoard produces
+const EncodingSize = 3*8 + 20 + 3*snake.FoodTypeCount
+
 type neuralInput struct {
 	feedForwardFunc network.FeedForward
+	policy          ActionPolicy
 }
 
-func NewNeuralInput(feedForwardFunc network.FeedForward) *neuralInput {
+func NewNeuralInput(feedForwardFunc network.FeedForward, policy ActionPolicy) *neuralInput {
 	input := &neuralInput{}
 	input.feedForwardFunc = feedForwardFunc
+	input.policy = policy
 
 	return input
 }
 
-func (input *neuralInput) HandleInput(game *snake.Game, snake *snake.Snake) {
-	encoding := EncodeGameBoard(game)
+func (input *neuralInput) Init() {}
+
+func (input *neuralInput) Reset() {}
+
+// Poll does nothing, the network only needs to look at the board when it's asked for a move
+func (input *neuralInput) Poll() {}
+
+func (input *neuralInput) HandleInput(game *snake.Game, player *snake.Snake) {
+	encoding := EncodeGameBoard(game, player)
 	output := input.feedForwardFunc(encoding)
 
-	// find the maximum value of the output and set the direction to that
-	maxIndex := 0
-	for i := 0; i < len(output); i++ {
-		if output[i] > output[maxIndex] {
-			maxIndex = i
-		}
-	}
+	direction := input.policy.SelectAction(game, player, output)
 
 	// print the outputs in one console line
 	// for i := 0; i < len(output); i++ {
 	// 	fmt.Printf("%f ", output[i])
 	// }
-	// fmt.Printf(" %d\n", maxIndex)
+	// fmt.Printf(" %d\n", direction)
 
-	snake.TargetDirection = maxIndex
+	player.TargetDirection = direction
 }
 
-func ScanDirection(game *snake.Game, slopeX, slopeY int) (float64, float64, float64) {
+// ScanDirection looks out from the player's head, other snakes in an arena are seen the same as its own body
+func ScanDirection(game *snake.Game, player *snake.Snake, slopeX, slopeY int) (float64, float64, float64) {
 	snakeDistance := math.MaxFloat64
 	foodDistance := math.MaxFloat64
 	totalDistance := 0.0
 
 	foundFood := false
 	foundSnake := false
+	hitObstacle := false
 
-	scanX := game.Snake.Head.X
-	scanY := game.Snake.Head.Y
+	scanX := player.Head.X
+	scanY := player.Head.Y
 
-	// while scan position are in the game board bounds
-	for scanX >= 0 && scanY >= 0 && scanX < snake.BoardWidth && scanY < snake.BoardHeight {
+	// while scan position are in the game board bounds, a wrapped board has no bounds so
+	// the scan instead stops once it comes back around to the head
+	for snake.InBounds(scanX, scanY) {
 		scanX += slopeX
 		scanY += slopeY
 		totalDistance += 1
 
-		// check if scan is food
-		if !foundFood && scanX == game.Food.X && scanY == game.Food.Y {
+		if game.Config.Wrap {
+			scanX, scanY = snake.WrapLocation(scanX, scanY)
+			if scanX == player.Head.X && scanY == player.Head.Y {
+				break
+			}
+		}
+
+		// obstacles block vision the same way the board edges do
+		if game.IsWall(scanX, scanY) {
+			hitObstacle = true
+			break
+		}
+
+		// check if scan is food, poison isn't something the snake should be looking for
+		if index := game.FoodAt(scanX, scanY); !foundFood && index != -1 && game.Foods[index].Type != snake.PoisonFood {
 			foodDistance = totalDistance
 			foundFood = true
 		}
 
 		// check if scan is snake
-		if !foundSnake && game.Snake.ContainsLocation(scanX, scanY, true) {
+		if !foundSnake && game.IsSnake(scanX, scanY) {
 			snakeDistance = totalDistance
 			foundSnake = true
 		}
 	}
 
 	wallDistance := 1.0 / totalDistance
+	if game.Config.Wrap && !hitObstacle {
+		// there are no walls to see
+		wallDistance = 0
+	}
 	snakeDistance = 1.0 / snakeDistance
 	foodDistance = 1.0 / foodDistance
 
 	return wallDistance, snakeDistance, foodDistance
 }
 
-func EncodeGameBoard(game *snake.Game) []float64 {
+func EncodeGameBoard(game *snake.Game, player *snake.Snake) []float64 {
 	//encoding := make([]float64, snake.BoardWidth*snake.BoardHeight+20)
 	// #region encode all the board
 	// encode the entire board if the snake is there vs not there
@@ -93,36 +119,46 @@ func EncodeGameBoard(game *snake.Game) []float64 {
 	//offset := snake.BoardWidth * snake.BoardHeight
 	// #endregion
 
-	encoding := make([]float64, 3*8+20)
+	encoding := make([]float64, EncodingSize)
 	startVision := 0
-	encoding[startVision+0], encoding[startVision+1], encoding[startVision+2] = ScanDirection(game, 0, 1)
-	encoding[startVision+3], encoding[startVision+4], encoding[startVision+5] = ScanDirection(game, 1, 1)
-	encoding[startVision+6], encoding[startVision+7], encoding[startVision+8] = ScanDirection(game, 1, 0)
-	encoding[startVision+9], encoding[startVision+10], encoding[startVision+11] = ScanDirection(game, 1, -1)
-	encoding[startVision+12], encoding[startVision+13], encoding[startVision+14] = ScanDirection(game, 0, -1)
-	encoding[startVision+15], encoding[startVision+16], encoding[startVision+17] = ScanDirection(game, -1, -1)
-	encoding[startVision+18], encoding[startVision+19], encoding[startVision+20] = ScanDirection(game, -1, 0)
-	encoding[startVision+21], encoding[startVision+22], encoding[startVision+23] = ScanDirection(game, -1, 1)
+	encoding[startVision+0], encoding[startVision+1], encoding[startVision+2] = ScanDirection(game, player, 0, 1)
+	encoding[startVision+3], encoding[startVision+4], encoding[startVision+5] = ScanDirection(game, player, 1, 1)
+	encoding[startVision+6], encoding[startVision+7], encoding[startVision+8] = ScanDirection(game, player, 1, 0)
+	encoding[startVision+9], encoding[startVision+10], encoding[startVision+11] = ScanDirection(game, player, 1, -1)
+	encoding[startVision+12], encoding[startVision+13], encoding[startVision+14] = ScanDirection(game, player, 0, -1)
+	encoding[startVision+15], encoding[startVision+16], encoding[startVision+17] = ScanDirection(game, player, -1, -1)
+	encoding[startVision+18], encoding[startVision+19], encoding[startVision+20] = ScanDirection(game, player, -1, 0)
+	encoding[startVision+21], encoding[startVision+22], encoding[startVision+23] = ScanDirection(game, player, -1, 1)
 
 	offset := startVision
 
-	encoding[offset+0] = float64(game.Food.X)
-	encoding[offset+1] = float64(game.Food.Y)
-	encoding[offset+2] = float64(game.Snake.Head.X)
-	encoding[offset+3] = float64(game.Snake.Head.Y)
+	food := nearestApple(game, player)
+	encoding[offset+0] = float64(food.X)
+	encoding[offset+1] = float64(food.Y)
+	encoding[offset+2] = float64(player.Head.X)
+	encoding[offset+3] = float64(player.Head.Y)
 
 	// distance to apple, normalized to 0-1, 0 being on top of the apple, 1 being on the opposite corner
-	encoding[offset+4] = float64(game.Snake.Head.X-game.Food.X) / float64(snake.BoardWidth)
-	encoding[offset+5] = float64(game.Snake.Head.Y-game.Food.Y) / float64(snake.BoardHeight)
-
-	// distance to left wall
-	encoding[offset+6] = float64(game.Snake.Head.X) / float64(snake.BoardWidth)
-	// distance to right wall
-	encoding[offset+7] = float64(snake.BoardWidth-game.Snake.Head.X) / float64(snake.BoardWidth)
-	// distance to top wall
-	encoding[offset+8] = float64(game.Snake.Head.Y) / float64(snake.BoardHeight)
-	// distance to bottom wall
-	encoding[offset+9] = float64(snake.BoardHeight-game.Snake.Head.Y) / float64(snake.BoardHeight)
+	// on a wrapped board this is the shortest way around, so at most half the board
+	encoding[offset+4] = float64(boardDelta(game, player.Head.X-food.X, snake.BoardWidth)) / float64(snake.BoardWidth)
+	encoding[offset+5] = float64(boardDelta(game, player.Head.Y-food.Y, snake.BoardHeight)) / float64(snake.BoardHeight)
+
+	if game.Config.Wrap {
+		// no walls on a wrapped board
+		encoding[offset+6] = 0
+		encoding[offset+7] = 0
+		encoding[offset+8] = 0
+		encoding[offset+9] = 0
+	} else {
+		// distance to left wall
+		encoding[offset+6] = float64(player.Head.X) / float64(snake.BoardWidth)
+		// distance to right wall
+		encoding[offset+7] = float64(snake.BoardWidth-player.Head.X) / float64(snake.BoardWidth)
+		// distance to top wall
+		encoding[offset+8] = float64(player.Head.Y) / float64(snake.BoardHeight)
+		// distance to bottom wall
+		encoding[offset+9] = float64(snake.BoardHeight-player.Head.Y) / float64(snake.BoardHeight)
+	}
 
 	// one hot encode the head direction as UP, RIGHT, DOWN, LEFT
 	encoding[offset+10] = 0
@@ -130,11 +166,11 @@ func EncodeGameBoard(game *snake.Game) []float64 {
 	encoding[offset+12] = 0
 	encoding[offset+13] = 0
 
-	encoding[offset+10+game.Snake.Direction] = 1
+	encoding[offset+10+player.Direction] = 1
 
 	// set tail location to end of tail
-	encoding[offset+14] = float64(game.Snake.Tail[len(game.Snake.Tail)-1].X)
-	encoding[offset+15] = float64(game.Snake.Tail[len(game.Snake.Tail)-1].Y)
+	encoding[offset+14] = float64(player.Tail[len(player.Tail)-1].X)
+	encoding[offset+15] = float64(player.Tail[len(player.Tail)-1].Y)
 	// one hot encode the tail direction as UP, RIGHT, DOWN, LEFT
 	encoding[offset+16] = 0
 	encoding[offset+17] = 0
@@ -142,5 +178,43 @@ func EncodeGameBoard(game *snake.Game) []float64 {
 	encoding[offset+19] = 0
 	// TODO: implement
 
+	// nearest food of each type as whether there is one and the normalized distance to it
+	foodOffset := 3*8 + 20
+	for foodType := snake.FoodType(0); foodType < snake.FoodTypeCount; foodType++ {
+		index := foodOffset + 3*int(foodType)
+		if food, ok := game.NearestFood(player.Head, foodType); ok {
+			encoding[index+0] = 1
+			encoding[index+1] = float64(boardDelta(game, player.Head.X-food.X, snake.BoardWidth)) / float64(snake.BoardWidth)
+			encoding[index+2] = float64(boardDelta(game, player.Head.Y-food.Y, snake.BoardHeight)) / float64(snake.BoardHeight)
+		}
+	}
+
 	return encoding
 }
+
+// nearestApple is the food the original single food features describe, the nearest normal food if there is one
+func nearestApple(game *snake.Game, player *snake.Snake) snake.Location {
+	if food, ok := game.NearestFood(player.Head, snake.NormalFood); ok {
+		return food.Location
+	}
+
+	if len(game.Foods) > 0 {
+		return game.Foods[0].Location
+	}
+
+	return player.Head
+}
+
+// boardDelta returns the signed distance along one axis, taking the shorter way around on a wrapped board
+func boardDelta(game *snake.Game, delta, size int) int {
+	if !game.Config.Wrap {
+		return delta
+	}
+
+	delta = (delta%size + size) % size
+	if delta > size/2 {
+		delta -= size
+	}
+
+	return delta
+}
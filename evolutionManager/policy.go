@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shusako/go_snake_neural_network/snakegame/snake"
+)
+
+// ActionPolicy turns the raw network outputs into the direction the snake should take
+type ActionPolicy interface {
+	SelectAction(game *snake.Game, snake *snake.Snake, output []float64) int
+	// Deterministic reports whether the policy always picks the same direction for the same outputs, games played
+	// with one that doesn't can't end on loop detection
+	Deterministic() bool
+	String() string
+}
+
+// ArgmaxPolicy always picks the direction with the highest output
+type ArgmaxPolicy struct{}
+
+func (policy *ArgmaxPolicy) SelectAction(game *snake.Game, snake *snake.Snake, output []float64) int {
+	return argmax(output, nil)
+}
+
+func (policy *ArgmaxPolicy) Deterministic() bool {
+	return true
+}
+
+func (policy *ArgmaxPolicy) String() string {
+	return "argmax"
+}
+
+// LegalArgmaxPolicy picks the direction with the highest output among the ones that don't kill the snake on its
+// next move, see snake.Game.IsSafeDirection. When every direction is deadly it still won't pick reversing.
+type LegalArgmaxPolicy struct{}
+
+func (policy *LegalArgmaxPolicy) SelectAction(game *snake.Game, player *snake.Snake, output []float64) int {
+	safe := func(direction int) bool {
+		return game.IsSafeDirection(player, direction)
+	}
+	if direction := argmax(output, safe); direction != -1 {
+		return direction
+	}
+
+	return argmax(output, player.IsLegalDirection)
+}
+
+func (policy *LegalArgmaxPolicy) Deterministic() bool {
+	return true
+}
+
+func (policy *LegalArgmaxPolicy) String() string {
+	return "legal-argmax"
+}
+
+// SoftmaxPolicy samples a direction from the softmax of the outputs,
+// a temperature close to 0 acts like argmax while a high temperature acts like random moves
+type SoftmaxPolicy struct {
+	Temperature float64
+}
+
+func (policy *SoftmaxPolicy) SelectAction(game *snake.Game, snake *snake.Snake, output []float64) int {
+	if policy.Temperature <= 0 {
+		return argmax(output, nil)
+	}
+
+	// subtract the max before exponentiating so large outputs don't overflow
+	maxOutput := output[argmax(output, nil)]
+	probabilities := make([]float64, len(output))
+	total := 0.0
+	for i := 0; i < len(output); i++ {
+		probabilities[i] = math.Exp((output[i] - maxOutput) / policy.Temperature)
+		total += probabilities[i]
+	}
+
+	sample := rand.Float64() * total
+	for i := 0; i < len(probabilities); i++ {
+		sample -= probabilities[i]
+		if sample < 0 {
+			return i
+		}
+	}
+
+	return len(probabilities) - 1
+}
+
+// Deterministic is only true for a temperature of 0 or below, which acts like argmax
+func (policy *SoftmaxPolicy) Deterministic() bool {
+	return policy.Temperature <= 0
+}
+
+func (policy *SoftmaxPolicy) String() string {
+	return fmt.Sprintf("softmax(t=%g)", policy.Temperature)
+}
+
+// EpsilonGreedyPolicy takes a random direction with probability Epsilon, otherwise defers to Policy
+type EpsilonGreedyPolicy struct {
+	Epsilon float64
+	Policy  ActionPolicy
+}
+
+func (policy *EpsilonGreedyPolicy) SelectAction(game *snake.Game, snake *snake.Snake, output []float64) int {
+	if rand.Float64() < policy.Epsilon {
+		return rand.Intn(len(output))
+	}
+
+	return policy.Policy.SelectAction(game, snake, output)
+}
+
+func (policy *EpsilonGreedyPolicy) Deterministic() bool {
+	return policy.Epsilon == 0 && policy.Policy.Deterministic()
+}
+
+func (policy *EpsilonGreedyPolicy) String() string {
+	return fmt.Sprintf("epsilon-greedy(e=%g, %s)", policy.Epsilon, policy.Policy)
+}
+
+// argmax finds the index of the highest output, if allowed is given then only directions it allows are considered
+// and -1 is returned when it allows none
+func argmax(output []float64, allowed func(direction int) bool) int {
+	maxIndex := -1
+	for i := 0; i < len(output); i++ {
+		if allowed != nil && !allowed(i) {
+			continue
+		}
+
+		if maxIndex == -1 || output[i] > output[maxIndex] {
+			maxIndex = i
+		}
+	}
+
+	return maxIndex
+}
+
+// ParsePolicy reads a policy as given on the command line: argmax, legal-argmax, softmax=<temperature> or
+// epsilon-greedy=<epsilon>, the last two default to a temperature of 1 and an epsilon of 0.05
+func ParsePolicy(name string) (ActionPolicy, error) {
+	kind, value, hasValue := strings.Cut(name, "=")
+	parameter := func(fallback float64) (float64, error) {
+		if !hasValue {
+			return fallback, nil
+		}
+		return strconv.ParseFloat(value, 64)
+	}
+
+	switch kind {
+	case "argmax", "legal-argmax":
+		if hasValue {
+			return nil, fmt.Errorf("policy %s takes no value", kind)
+		}
+		if kind == "argmax" {
+			return &ArgmaxPolicy{}, nil
+		}
+		return &LegalArgmaxPolicy{}, nil
+	case "softmax":
+		temperature, err := parameter(1)
+		if err != nil {
+			return nil, fmt.Errorf("bad softmax temperature: %w", err)
+		}
+		return &SoftmaxPolicy{Temperature: temperature}, nil
+	case "epsilon-greedy":
+		epsilon, err := parameter(0.05)
+		if err != nil || epsilon < 0 || epsilon > 1 {
+			return nil, fmt.Errorf("bad epsilon %q, it has to be between 0 and 1", value)
+		}
+		return &EpsilonGreedyPolicy{Epsilon: epsilon, Policy: &ArgmaxPolicy{}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown policy %q, use argmax, legal-argmax, softmax=<temperature> or epsilon-greedy=<epsilon>", name)
+}
+
+// savedPolicy records which policy a saved network was trained with, next to the network in <network>.policy.json
+type savedPolicy struct {
+	Policy string `json:"policy"`
+}
+
+// SavePolicy records the policy, as given to ParsePolicy, the network at networkPath was trained with
+func SavePolicy(networkPath string, name string) error {
+	data, err := json.Marshal(savedPolicy{Policy: name})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(networkPath+".policy.json", data, 0644)
+}
+
+// LoadPolicy reads the policy recorded by SavePolicy for the network at networkPath, networks saved without one
+// were trained with argmax
+func LoadPolicy(networkPath string) (string, error) {
+	data, err := os.ReadFile(networkPath + ".policy.json")
+	if errors.Is(err, os.ErrNotExist) {
+		return "argmax", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var saved savedPolicy
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return "", err
+	}
+
+	return saved.Policy, nil
+}
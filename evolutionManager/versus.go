@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/shusako/go_snake_neural_network/network"
+	"github.com/shusako/go_snake_neural_network/snakegame/snake"
+	"golang.org/x/image/font/basicfont"
+)
+
+// versusInterval is how fast the games run when a person is playing
+const versusInterval = 150 * time.Millisecond
+
+// versusViewer lets a person play against a network, either on one board or side by side on boards with the same seed
+type versusViewer struct {
+	split bool
+	human *snake.UserInput
+	ai    *neuralInput
+
+	// one board shared by both snakes, the human is snake 0
+	arena *snake.Arena
+	// side by side boards
+	humanGame *snake.Game
+	aiGame    *snake.Game
+	// shared by both boards so they move together
+	clock snake.Clock
+
+	round     int
+	roundOver bool
+	humanWins int
+	aiWins    int
+	draws     int
+}
+
+// NewVersusViewer loads the network saved at networkPath for the AI, it has to read the board encoding and give one
+// output per direction
+func NewVersusViewer(networkPath string, policy ActionPolicy, split bool) (*versusViewer, error) {
+	feedForward, inputs, outputs, err := network.LoadFeedForwardSized(networkPath)
+	if err != nil {
+		return nil, err
+	}
+	if inputs != EncodingSize || outputs != 4 {
+		return nil, fmt.Errorf("%s has %d inputs and %d outputs, the AI needs %d inputs and one output per direction", networkPath, inputs, outputs, EncodingSize)
+	}
+
+	viewer := &versusViewer{
+		split: split,
+		clock: &snake.RealTimeClock{Interval: versusInterval},
+		human: &snake.UserInput{},
+		ai:    NewNeuralInput(feedForward, policy),
+	}
+	viewer.human.Init()
+	viewer.ai.Init()
+
+	if split {
+		viewer.humanGame = &snake.Game{Input: viewer.human}
+		// the AI is stopped once it starts circling so the round can't go on forever, a policy with random moves can
+		// get out of a circle so it is only stopped by starving
+		viewer.aiGame = &snake.Game{Input: viewer.ai, Config: snake.Config{DetectLoops: policy.Deterministic()}}
+	} else {
+		arena, err := snake.NewArena(snake.Config{}, []snake.Input{viewer.human, viewer.ai})
+		if err != nil {
+			return nil, err
+		}
+		viewer.arena = arena
+	}
+
+	viewer.newRound()
+
+	return viewer, nil
+}
+
+func (v *versusViewer) newRound() {
+	seed := time.Now().UnixNano()
+	v.round++
+	v.roundOver = false
+
+	if v.split {
+		v.humanGame.Seed(seed)
+		v.humanGame.Reset()
+		v.aiGame.Seed(seed)
+		v.aiGame.Reset()
+	} else {
+		v.arena.Game.Seed(seed)
+		v.arena.Reset()
+	}
+}
+
+func (v *versusViewer) Update() error {
+	if v.roundOver {
+		if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+			v.newRound()
+		}
+		return nil
+	}
+
+	if v.split {
+		snake.Advance(v.clock, v.humanGame, v.aiGame)
+
+		if v.humanGame.IsOver && v.aiGame.IsOver {
+			v.scoreRound(compareGames(v.humanGame, v.aiGame))
+		}
+	} else {
+		snake.Advance(v.clock, v.arena)
+
+		if v.arena.IsOver {
+			placements := v.arena.Placements()
+			v.scoreRound(placements[1] - placements[0])
+		}
+	}
+
+	return nil
+}
+
+// compareGames is above 0 if the human did better, below 0 if the AI did, apples count first then moves survived
+func compareGames(humanGame, aiGame *snake.Game) int {
+	humanApples := countApples(&humanGame.Snake)
+	aiApples := countApples(&aiGame.Snake)
+	if humanApples != aiApples {
+		if humanApples > aiApples {
+			return 1
+		}
+		return -1
+	}
+
+	return humanGame.Moves - aiGame.Moves
+}
+
+func (v *versusViewer) scoreRound(result int) {
+	v.roundOver = true
+
+	if result > 0 {
+		v.humanWins++
+	} else if result < 0 {
+		v.aiWins++
+	} else {
+		v.draws++
+	}
+}
+
+func (v *versusViewer) Draw(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{0x21, 0x21, 0x21, 0xff})
+
+	scoreboard := fmt.Sprintf("Round %d    You: %d    AI: %d    Draws: %d", v.round, v.humanWins, v.aiWins, v.draws)
+	text.Draw(screen, scoreboard, basicfont.Face7x13, 10, 20, color.White)
+	if v.roundOver {
+		text.Draw(screen, "Press space for the next round", basicfont.Face7x13, 10, 36, color.White)
+	}
+
+	padding := 10
+	top := 50
+	if v.split {
+		text.Draw(screen, fmt.Sprintf("You - apples: %.0f", countApples(&v.humanGame.Snake)), basicfont.Face7x13, padding, top-4, color.White)
+		text.Draw(screen, fmt.Sprintf("AI - apples: %.0f", countApples(&v.aiGame.Snake)), basicfont.Face7x13, ScreenWidth/2+padding, top-4, color.White)
+		DrawInRegion(screen, v.humanGame, padding, top, ScreenWidth/2-padding, ScreenHeight-padding)
+		DrawInRegion(screen, v.aiGame, ScreenWidth/2+padding, top, ScreenWidth-padding, ScreenHeight-padding)
+	} else {
+		humanColor := snake.ArenaColor(0)
+		aiColor := snake.ArenaColor(1)
+		text.Draw(screen, fmt.Sprintf("You - apples: %.0f", countApples(v.arena.Snakes[0])), basicfont.Face7x13, 10, top-4, humanColor)
+		text.Draw(screen, fmt.Sprintf("AI - apples: %.0f", countApples(v.arena.Snakes[1])), basicfont.Face7x13, 200, top-4, aiColor)
+		DrawInRegion(screen, v.arena, ScreenWidth/4, top, ScreenWidth*3/4, ScreenHeight-padding)
+	}
+}
+
+func (v *versusViewer) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return ScreenWidth, ScreenHeight
+}
@@ -0,0 +1,219 @@
+package network
+
+import (
+	"fmt"
+	"math"
+)
+
+// ActivationFunction is the function a layer applies to its outputs
+type ActivationFunction int
+
+const (
+	Sigmoid ActivationFunction = iota
+	Tanh
+	ReLU
+	// LeakyReLU multiplies values below 0 by Activation.Alpha
+	LeakyReLU
+	// ELU is Activation.Alpha * (e^x - 1) below 0
+	ELU
+	// Softmax turns the whole layer into probabilities that add up to 1
+	Softmax
+	Identity
+	// Gaussian is e^(-x^2)
+	Gaussian
+)
+
+var activationNames = map[ActivationFunction]string{
+	Sigmoid:   "sigmoid",
+	Tanh:      "tanh",
+	ReLU:      "relu",
+	LeakyReLU: "leaky_relu",
+	ELU:       "elu",
+	Softmax:   "softmax",
+	Identity:  "identity",
+	Gaussian:  "gaussian",
+}
+
+func (function ActivationFunction) String() string {
+	if name, ok := activationNames[function]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// MarshalText saves activation functions by name so saved networks don't depend on the order of the constants
+func (function ActivationFunction) MarshalText() ([]byte, error) {
+	name, ok := activationNames[function]
+	if !ok {
+		return nil, fmt.Errorf("unknown activation function %d", int(function))
+	}
+
+	return []byte(name), nil
+}
+
+func (function *ActivationFunction) UnmarshalText(text []byte) error {
+	for value, name := range activationNames {
+		if name == string(text) {
+			*function = value
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown activation function %q", text)
+}
+
+// Activation is an activation function along with its parameter
+type Activation struct {
+	Function ActivationFunction `json:"function"`
+	// slope below 0 for LeakyReLU, the value ELU approaches below 0
+	Alpha float64 `json:"alpha,omitempty"`
+}
+
+func (activation Activation) String() string {
+	if activation.Function == LeakyReLU || activation.Function == ELU {
+		return fmt.Sprintf("%s(%g)", activation.Function, activation.Alpha)
+	}
+
+	return activation.Function.String()
+}
+
+// apply runs the activation over a layer's outputs in place
+func (activation Activation) apply(output []float64) {
+	switch activation.Function {
+	case Sigmoid:
+		for i, value := range output {
+			output[i] = 1 / (1 + math.Exp(-value))
+		}
+	case Tanh:
+		for i, value := range output {
+			output[i] = math.Tanh(value)
+		}
+	case ReLU:
+		for i, value := range output {
+			if value < 0 {
+				output[i] = 0
+			}
+		}
+	case LeakyReLU:
+		for i, value := range output {
+			if value < 0 {
+				output[i] = activation.Alpha * value
+			}
+		}
+	case ELU:
+		for i, value := range output {
+			if value < 0 {
+				output[i] = activation.Alpha * (math.Exp(value) - 1)
+			}
+		}
+	case Softmax:
+		// subtract the largest value first so the exponentials can't overflow
+		largest := math.Inf(-1)
+		for _, value := range output {
+			largest = math.Max(largest, value)
+		}
+
+		sum := 0.0
+		for i, value := range output {
+			output[i] = math.Exp(value - largest)
+			sum += output[i]
+		}
+		for i := range output {
+			output[i] /= sum
+		}
+	case Gaussian:
+		for i, value := range output {
+			output[i] = math.Exp(-value * value)
+		}
+	}
+}
+
+// backward turns the gradient of a layer's outputs into the gradient of its weighted sums in place, given the sums
+// and the outputs apply made from them
+func (activation Activation) backward(sums, outputs, gradient []float64) {
+	switch activation.Function {
+	case Sigmoid:
+		for i, output := range outputs {
+			gradient[i] *= output * (1 - output)
+		}
+	case Tanh:
+		for i, output := range outputs {
+			gradient[i] *= 1 - output*output
+		}
+	case ReLU:
+		for i, sum := range sums {
+			if sum < 0 {
+				gradient[i] = 0
+			}
+		}
+	case LeakyReLU:
+		for i, sum := range sums {
+			if sum < 0 {
+				gradient[i] *= activation.Alpha
+			}
+		}
+	case ELU:
+		for i, sum := range sums {
+			if sum < 0 {
+				gradient[i] *= outputs[i] + activation.Alpha
+			}
+		}
+	case Softmax:
+		// every output depends on every sum, the Jacobian is diag(outputs) - outputs * outputs^T
+		dot := 0.0
+		for i, output := range outputs {
+			dot += gradient[i] * output
+		}
+		for i, output := range outputs {
+			gradient[i] = output * (gradient[i] - dot)
+		}
+	case Gaussian:
+		for i, sum := range sums {
+			gradient[i] *= -2 * sum * outputs[i]
+		}
+	}
+}
+
+// apply32 is apply for the float32 backend, the functions are computed in float64
+func (activation Activation) apply32(output []float32) {
+	switch activation.Function {
+	case ReLU:
+		for i, value := range output {
+			if value < 0 {
+				output[i] = 0
+			}
+		}
+	case LeakyReLU:
+		alpha := float32(activation.Alpha)
+		for i, value := range output {
+			if value < 0 {
+				output[i] = alpha * value
+			}
+		}
+	case Identity:
+	case Softmax:
+		largest := float32(math.Inf(-1))
+		for _, value := range output {
+			if value > largest {
+				largest = value
+			}
+		}
+
+		var sum float32
+		for i, value := range output {
+			output[i] = float32(math.Exp(float64(value - largest)))
+			sum += output[i]
+		}
+		for i := range output {
+			output[i] /= sum
+		}
+	default:
+		var value [1]float64
+		for i := range output {
+			value[0] = float64(output[i])
+			activation.apply(value[:])
+			output[i] = float32(value[0])
+		}
+	}
+}
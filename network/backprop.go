@@ -0,0 +1,147 @@
+package network
+
+// Backprop runs a network while keeping what it needs to work out the gradient of the outputs with respect to every
+// weight and bias, so the network can be trained by gradient descent. It has its own buffers and belongs to one
+// goroutine, the network's parameters must not change between Forward and Backward.
+type Backprop struct {
+	network *Network
+
+	// last input and the weighted sums and outputs of each layer for it
+	input   []float64
+	sums    [][]float64
+	outputs [][]float64
+	// gradient of each layer's weighted sums, and of the input
+	deltas        [][]float64
+	inputGradient []float64
+
+	// gradient summed over every Backward since the last ZeroGradient, laid out like the genome
+	gradient        []float64
+	gradientWeights [][]float64
+	gradientBiases  [][]float64
+}
+
+func (n *Network) NewBackprop() *Backprop {
+	backprop := &Backprop{
+		network:       n,
+		input:         make([]float64, n.spec.Sizes[0]),
+		inputGradient: make([]float64, n.spec.Sizes[0]),
+	}
+
+	for _, biases := range n.biases {
+		backprop.sums = append(backprop.sums, make([]float64, len(biases)))
+		backprop.outputs = append(backprop.outputs, make([]float64, len(biases)))
+		backprop.deltas = append(backprop.deltas, make([]float64, len(biases)))
+	}
+
+	// a network of the same spec lays its parameters out the same way
+	gradient := newNetwork(n.spec)
+	backprop.gradient = gradient.parameters
+	backprop.gradientWeights = gradient.weights
+	backprop.gradientBiases = gradient.biases
+
+	return backprop
+}
+
+// Forward runs the network like FeedForward and remembers the input for Backward, the returned slice is overwritten
+// by the next call
+func (backprop *Backprop) Forward(input []float64) []float64 {
+	if len(input) != len(backprop.input) {
+		panic("Input size does not match network input size")
+	}
+	copy(backprop.input, input)
+
+	for layer, weights := range backprop.network.weights {
+		sums := backprop.sums[layer]
+		copy(sums, backprop.network.biases[layer])
+
+		for inputNeuronIndex, value := range input {
+			row := weights[inputNeuronIndex*len(sums) : (inputNeuronIndex+1)*len(sums)]
+			for outputNeuronIndex, weight := range row {
+				sums[outputNeuronIndex] += value * weight
+			}
+		}
+
+		output := backprop.outputs[layer]
+		copy(output, sums)
+		backprop.network.activations[layer].apply(output)
+
+		input = output
+	}
+
+	return input
+}
+
+// Backward adds the gradient of the last Forward to the gradient, given the gradient of whatever is being minimized
+// with respect to each output. It returns the gradient with respect to the input, which is overwritten by the next call.
+func (backprop *Backprop) Backward(outputGradient []float64) []float64 {
+	last := len(backprop.deltas) - 1
+	if len(outputGradient) != len(backprop.deltas[last]) {
+		panic("Gradient size does not match network output size")
+	}
+	copy(backprop.deltas[last], outputGradient)
+
+	for layer := last; layer >= 0; layer-- {
+		delta := backprop.deltas[layer]
+		backprop.network.activations[layer].backward(backprop.sums[layer], backprop.outputs[layer], delta)
+
+		input, inputDelta := backprop.input, backprop.inputGradient
+		if layer > 0 {
+			input, inputDelta = backprop.outputs[layer-1], backprop.deltas[layer-1]
+		}
+
+		weights := backprop.network.weights[layer]
+		gradientWeights := backprop.gradientWeights[layer]
+		for inputNeuronIndex, value := range input {
+			offset := inputNeuronIndex * len(delta)
+			sum := 0.0
+			for outputNeuronIndex, d := range delta {
+				gradientWeights[offset+outputNeuronIndex] += value * d
+				sum += weights[offset+outputNeuronIndex] * d
+			}
+			inputDelta[inputNeuronIndex] = sum
+		}
+
+		for outputNeuronIndex, d := range delta {
+			backprop.gradientBiases[layer][outputNeuronIndex] += d
+		}
+	}
+
+	return backprop.inputGradient
+}
+
+// Gradient is the summed gradient, laid out like Genome, changing it changes the gradient
+func (backprop *Backprop) Gradient() []float64 {
+	return backprop.gradient
+}
+
+func (backprop *Backprop) ZeroGradient() {
+	for i := range backprop.gradient {
+		backprop.gradient[i] = 0
+	}
+}
+
+// Train takes one step of gradient descent on a batch of inputs and the outputs the network should give for them,
+// with the gradient averaged over the batch, any gradient summed before is dropped. It returns the average loss from
+// before the step.
+func (backprop *Backprop) Train(inputs, targets [][]float64, loss Loss, optimizer GradientOptimizer) float64 {
+	if len(inputs) != len(targets) {
+		panic("Every input needs a target")
+	}
+
+	last := len(backprop.deltas) - 1
+	outputGradient := make([]float64, len(backprop.deltas[last]))
+	total := 0.0
+
+	backprop.ZeroGradient()
+	for i, input := range inputs {
+		total += loss.Evaluate(backprop.Forward(input), targets[i], outputGradient)
+		for j := range outputGradient {
+			outputGradient[j] /= float64(len(inputs))
+		}
+		backprop.Backward(outputGradient)
+	}
+	optimizer.Step(backprop.network, backprop.gradient)
+	backprop.ZeroGradient()
+
+	return total / float64(len(inputs))
+}
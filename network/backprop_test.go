@@ -0,0 +1,81 @@
+package network
+
+import (
+	"math"
+	"testing"
+)
+
+// numericGradient is the gradient of the sum of the outputs times weights by central differences
+func numericGradient(network *Network, input, weights []float64) []float64 {
+	loss := func() float64 {
+		sum := 0.0
+		for i, output := range network.Forward(input) {
+			sum += output * weights[i]
+		}
+		return sum
+	}
+
+	gradient := make([]float64, len(network.parameters))
+	for i := range network.parameters {
+		value := network.parameters[i]
+		network.parameters[i] = value + 1e-6
+		above := loss()
+		network.parameters[i] = value - 1e-6
+		below := loss()
+		network.parameters[i] = value
+		gradient[i] = (above - below) / 2e-6
+	}
+
+	return gradient
+}
+
+func TestBackpropMatchesFiniteDifferences(t *testing.T) {
+	input := []float64{0.3, -0.7, 1.1}
+	weights := []float64{0.5, -1, 2}
+
+	for function := range activationNames {
+		activation := Activation{Function: function, Alpha: 0.1}
+		spec := Spec{
+			Sizes:       []int{3, 4, 3},
+			Activations: []Activation{activation, activation},
+			WeightInit:  Uniform,
+			BiasInit:    Uniform,
+		}
+		network := NewNetwork(spec)
+
+		backprop := network.NewBackprop()
+		backprop.Forward(input)
+		backprop.Backward(weights)
+
+		expected := numericGradient(network, input, weights)
+		for i, value := range backprop.Gradient() {
+			if math.Abs(value-expected[i]) > 1e-5 {
+				t.Errorf("%s: parameter %d has gradient %v, expected %v", activation, i, value, expected[i])
+			}
+		}
+	}
+}
+
+func TestBackpropAccumulatesUntilZeroed(t *testing.T) {
+	network := NewNetwork(DefaultSpec(2, 3, 1))
+	backprop := network.NewBackprop()
+
+	backprop.Forward([]float64{1, 2})
+	backprop.Backward([]float64{1})
+	once := append([]float64(nil), backprop.Gradient()...)
+
+	backprop.Forward([]float64{1, 2})
+	backprop.Backward([]float64{1})
+	for i, value := range backprop.Gradient() {
+		if math.Abs(value-2*once[i]) > 1e-12 {
+			t.Fatalf("parameter %d has gradient %v after two passes, %v after one", i, value, once[i])
+		}
+	}
+
+	backprop.ZeroGradient()
+	for i, value := range backprop.Gradient() {
+		if value != 0 {
+			t.Fatalf("parameter %d has gradient %v after zeroing", i, value)
+		}
+	}
+}
@@ -0,0 +1,589 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// RestartStrategy decides what CMAES does once a run has converged or stalled
+type RestartStrategy int
+
+const (
+	// NoRestart keeps going with the converged run
+	NoRestart RestartStrategy = iota
+	// IPOP restarts with double the population every time, searching more globally each run
+	IPOP
+	// BIPOP alternates between IPOP's growing populations and short runs with small populations and small steps,
+	// whichever has used fewer evaluations so far
+	BIPOP
+)
+
+// CMAESConfig holds the settings of a CMAES run, start from DefaultCMAESConfig
+type CMAESConfig struct {
+	// starting step size, the spread of the first samples around the starting network
+	Sigma float64
+	// candidates per generation, 0 uses 4 + 3 ln(parameters)
+	PopulationSize int
+	Restart        RestartStrategy
+	// IPOP runs stop doubling their population past this
+	MaxPopulationSize int
+	// games each candidate plays, its fitness is the median
+	Games int
+}
+
+func DefaultCMAESConfig() CMAESConfig {
+	return CMAESConfig{
+		Sigma:             0.5,
+		Restart:           BIPOP,
+		MaxPopulationSize: 2048,
+		Games:             5,
+	}
+}
+
+func (config CMAESConfig) validate() error {
+	if config.Sigma <= 0 {
+		return errors.New("CMA-ES needs a sigma above 0")
+	}
+	if config.PopulationSize < 0 || config.Games < 1 {
+		return errors.New("CMA-ES needs a population size of at least 0 and at least 1 game per candidate")
+	}
+
+	return nil
+}
+
+// cmaesCandidate is one sample of a generation
+type cmaesCandidate struct {
+	parameters []float64
+	// the sample before it was scaled by sigma and moved to the mean
+	step    []float64
+	fitness float64
+}
+
+// CMAES is the covariance matrix adaptation evolution strategy. It samples candidates from a normal distribution
+// around a mean network and adapts the full covariance of that distribution towards the steps that did well, so
+// it is only practical for networks of up to a few hundred parameters.
+type CMAES struct {
+	config CMAESConfig
+	spec   Spec
+	// network the candidates are written into to be evaluated
+	network *Network
+
+	generationNumber int
+	evaluations      int
+	evaluate         evaluateIndividual
+
+	// state of the current run
+	lambda     int
+	mu         int
+	weights    []float64
+	muEff      float64
+	cc, cs     float64
+	c1, cmu    float64
+	damps      float64
+	chiN       float64
+	mean       []float64
+	sigma      float64
+	covariance [][]float64
+	// covariance = eigenvectors * diag(scales^2) * eigenvectors^T
+	eigenvectors [][]float64
+	scales       []float64
+	pathC        []float64
+	pathSigma    []float64
+	// generations since the covariance was last decomposed, and since the run started
+	staleEigen    int
+	runGeneration int
+	// best fitness of recent generations, to notice when a run stops improving
+	recentBest []float64
+
+	candidates []*cmaesCandidate
+	best       *cmaesCandidate
+
+	// restart bookkeeping
+	restarts          int
+	defaultLambda     int
+	largeLambda       int
+	largeEvaluations  int
+	smallEvaluations  int
+	runEvaluations    int
+	runIsSmall        bool
+	evaluationsAtBest int
+}
+
+func NewCMAES(spec Spec, config CMAESConfig, evaluate evaluateIndividual) *CMAES {
+	if err := spec.validate(); err != nil {
+		panic(err)
+	}
+	if err := config.validate(); err != nil {
+		panic(err)
+	}
+
+	cmaes := &CMAES{
+		config:   config,
+		spec:     spec,
+		network:  newNetwork(spec),
+		evaluate: evaluate,
+	}
+
+	n := cmaes.network.ParameterCount()
+	cmaes.defaultLambda = config.PopulationSize
+	if cmaes.defaultLambda == 0 {
+		cmaes.defaultLambda = 4 + int(3*math.Log(float64(n)))
+	}
+	cmaes.largeLambda = cmaes.defaultLambda
+
+	cmaes.startRun(newRandomNetwork(spec).parameters, config.Sigma, cmaes.defaultLambda)
+
+	return cmaes
+}
+
+// startRun resets the distribution to a new mean, step size and population size
+func (cmaes *CMAES) startRun(mean []float64, sigma float64, lambda int) {
+	n := len(mean)
+	cmaes.lambda = lambda
+	cmaes.mu = lambda / 2
+	cmaes.mean = append([]float64(nil), mean...)
+	cmaes.sigma = sigma
+
+	// log weighted recombination of the best half
+	cmaes.weights = make([]float64, cmaes.mu)
+	sum, squares := 0.0, 0.0
+	for i := range cmaes.weights {
+		cmaes.weights[i] = math.Log(float64(cmaes.mu)+0.5) - math.Log(float64(i+1))
+		sum += cmaes.weights[i]
+	}
+	for i := range cmaes.weights {
+		cmaes.weights[i] /= sum
+		squares += cmaes.weights[i] * cmaes.weights[i]
+	}
+	cmaes.muEff = 1 / squares
+
+	dimensions, muEff := float64(n), cmaes.muEff
+	cmaes.cc = (4 + muEff/dimensions) / (dimensions + 4 + 2*muEff/dimensions)
+	cmaes.cs = (muEff + 2) / (dimensions + muEff + 5)
+	cmaes.c1 = 2 / ((dimensions+1.3)*(dimensions+1.3) + muEff)
+	cmaes.cmu = math.Min(1-cmaes.c1, 2*(muEff-2+1/muEff)/((dimensions+2)*(dimensions+2)+muEff))
+	cmaes.damps = 1 + 2*math.Max(0, math.Sqrt((muEff-1)/(dimensions+1))-1) + cmaes.cs
+	cmaes.chiN = math.Sqrt(dimensions) * (1 - 1/(4*dimensions) + 1/(21*dimensions*dimensions))
+
+	cmaes.covariance = make([][]float64, n)
+	cmaes.eigenvectors = make([][]float64, n)
+	for i := range cmaes.covariance {
+		cmaes.covariance[i] = make([]float64, n)
+		cmaes.covariance[i][i] = 1
+		cmaes.eigenvectors[i] = make([]float64, n)
+		cmaes.eigenvectors[i][i] = 1
+	}
+	cmaes.scales = make([]float64, n)
+	for i := range cmaes.scales {
+		cmaes.scales[i] = 1
+	}
+	cmaes.pathC = make([]float64, n)
+	cmaes.pathSigma = make([]float64, n)
+	cmaes.staleEigen = 0
+	cmaes.runGeneration = 0
+	cmaes.recentBest = cmaes.recentBest[:0]
+	cmaes.runEvaluations = 0
+}
+
+// EvaluateGeneration samples a generation of candidates around the mean and evaluates them
+func (cmaes *CMAES) EvaluateGeneration() {
+	n := len(cmaes.mean)
+	cmaes.candidates = make([]*cmaesCandidate, cmaes.lambda)
+	normal := make([]float64, n)
+	fitnessTrack := make([]float64, cmaes.config.Games)
+
+	for c := range cmaes.candidates {
+		for i := range normal {
+			normal[i] = cmaes.scales[i] * rand.NormFloat64()
+		}
+
+		candidate := &cmaesCandidate{parameters: make([]float64, n), step: make([]float64, n)}
+		for i := 0; i < n; i++ {
+			step := 0.0
+			for j := 0; j < n; j++ {
+				step += cmaes.eigenvectors[i][j] * normal[j]
+			}
+			candidate.step[i] = step
+			candidate.parameters[i] = cmaes.mean[i] + cmaes.sigma*step
+		}
+
+		copy(cmaes.network.parameters, candidate.parameters)
+		candidate.fitness = medianFitness(cmaes.evaluate, cmaes.network.feedForward, fitnessTrack)
+		cmaes.candidates[c] = candidate
+	}
+
+	cmaes.evaluations += cmaes.lambda * cmaes.config.Games
+	cmaes.runEvaluations += cmaes.lambda * cmaes.config.Games
+
+	sort.Slice(cmaes.candidates, func(i, j int) bool {
+		return cmaes.candidates[i].fitness > cmaes.candidates[j].fitness
+	})
+	if cmaes.best == nil || cmaes.candidates[0].fitness > cmaes.best.fitness {
+		cmaes.best = cmaes.candidates[0]
+		cmaes.evaluationsAtBest = cmaes.evaluations
+	}
+}
+
+func (cmaes *CMAES) bestNetwork() *Network {
+	network := newNetwork(cmaes.spec)
+	copy(network.parameters, cmaes.candidates[0].parameters)
+
+	return network
+}
+
+// GetBestIndividual prints the stats of the last evaluated generation and returns its best candidate
+func (cmaes *CMAES) GetBestIndividual() FeedForward {
+	fmt.Print(cmaes.Stats())
+
+	return cmaes.Best()
+}
+
+func (cmaes *CMAES) Best() FeedForward {
+	return cmaes.bestNetwork().newFeedForward()
+}
+
+// Stats has the state of the search as details, games is how many evaluations it took to find the best fitness so
+// far for comparing sample efficiency
+func (cmaes *CMAES) Stats() Stats {
+	return Stats{
+		Generation:  cmaes.generationNumber,
+		BestFitness: cmaes.candidates[0].fitness,
+		Details: []Detail{
+			detail("Sigma", "%g", cmaes.sigma),
+			detail("Population", "%d", cmaes.lambda),
+			detail("Restarts", "%d", cmaes.restarts),
+			detail("Games", "%d (best after %d)", cmaes.evaluations, cmaes.evaluationsAtBest),
+		},
+	}
+}
+
+// Step updates the distribution from the last evaluated generation, unless there is none yet, and samples and
+// evaluates a new one
+func (cmaes *CMAES) Step() {
+	if cmaes.candidates != nil {
+		cmaes.EvolveGeneration()
+	}
+	cmaes.EvaluateGeneration()
+}
+
+// BestNetwork is a copy of the best candidate of the last evaluated generation
+func (cmaes *CMAES) BestNetwork() *Network {
+	return cmaes.bestNetwork()
+}
+
+// SaveBest writes the best candidate of the last evaluated generation to path as JSON
+func (cmaes *CMAES) SaveBest(path string) error {
+	return cmaes.bestNetwork().Save(path)
+}
+
+// EvolveGeneration moves the mean towards the best candidates and adapts the step size and covariance
+func (cmaes *CMAES) EvolveGeneration() {
+	n := len(cmaes.mean)
+
+	// weighted average of the best steps, the mean moves by sigma times this
+	stepW := make([]float64, n)
+	for i, weight := range cmaes.weights {
+		for k, value := range cmaes.candidates[i].step {
+			stepW[k] += weight * value
+		}
+	}
+	for k := range cmaes.mean {
+		cmaes.mean[k] += cmaes.sigma * stepW[k]
+	}
+
+	// C^-1/2 * stepW = eigenvectors * diag(1/scales) * eigenvectors^T * stepW
+	rotated := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for k := 0; k < n; k++ {
+			rotated[j] += cmaes.eigenvectors[k][j] * stepW[k]
+		}
+		rotated[j] /= cmaes.scales[j]
+	}
+	whitened := make([]float64, n)
+	for k := 0; k < n; k++ {
+		for j := 0; j < n; j++ {
+			whitened[k] += cmaes.eigenvectors[k][j] * rotated[j]
+		}
+	}
+
+	cmaes.runGeneration++
+	normSigma := 0.0
+	sigmaRate := math.Sqrt(cmaes.cs * (2 - cmaes.cs) * cmaes.muEff)
+	for k := range cmaes.pathSigma {
+		cmaes.pathSigma[k] = (1-cmaes.cs)*cmaes.pathSigma[k] + sigmaRate*whitened[k]
+		normSigma += cmaes.pathSigma[k] * cmaes.pathSigma[k]
+	}
+	normSigma = math.Sqrt(normSigma)
+
+	// stall the covariance path while the step size path is long, so a big step size change doesn't distort C
+	hsig := 0.0
+	if normSigma/math.Sqrt(1-math.Pow(1-cmaes.cs, float64(2*cmaes.runGeneration)))/cmaes.chiN < 1.4+2/float64(n+1) {
+		hsig = 1
+	}
+	covarianceRate := math.Sqrt(cmaes.cc * (2 - cmaes.cc) * cmaes.muEff)
+	for k := range cmaes.pathC {
+		cmaes.pathC[k] = (1-cmaes.cc)*cmaes.pathC[k] + hsig*covarianceRate*stepW[k]
+	}
+
+	decay := 1 - cmaes.c1 - cmaes.cmu + (1-hsig)*cmaes.c1*cmaes.cc*(2-cmaes.cc)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			rankMu := 0.0
+			for c, weight := range cmaes.weights {
+				rankMu += weight * cmaes.candidates[c].step[i] * cmaes.candidates[c].step[j]
+			}
+
+			value := decay*cmaes.covariance[i][j] + cmaes.c1*cmaes.pathC[i]*cmaes.pathC[j] + cmaes.cmu*rankMu
+			cmaes.covariance[i][j] = value
+			cmaes.covariance[j][i] = value
+		}
+	}
+
+	cmaes.sigma *= math.Exp(cmaes.cs / cmaes.damps * (normSigma/cmaes.chiN - 1))
+
+	// decomposing is O(n^3), so it is only done often enough to keep up with how fast C changes
+	cmaes.staleEigen++
+	if float64(cmaes.staleEigen) > float64(cmaes.lambda)/(cmaes.c1+cmaes.cmu)/float64(n)/10 {
+		cmaes.decompose()
+	}
+
+	cmaes.recentBest = append(cmaes.recentBest, cmaes.candidates[0].fitness)
+	if cmaes.config.Restart != NoRestart && cmaes.converged() {
+		cmaes.restart()
+	}
+
+	cmaes.generationNumber++
+}
+
+func (cmaes *CMAES) decompose() {
+	cmaes.staleEigen = 0
+
+	values, vectors := symmetricEigen(cmaes.covariance)
+	cmaes.eigenvectors = vectors
+	for i, value := range values {
+		// rounding can leave tiny negative eigenvalues
+		cmaes.scales[i] = math.Sqrt(math.Max(value, 1e-20))
+	}
+}
+
+// converged reports whether the run has stopped making progress: its steps are tiny, the covariance is degenerate
+// or its best fitness hasn't moved or improved for a while
+func (cmaes *CMAES) converged() bool {
+	largest, smallest := 0.0, math.Inf(1)
+	for _, scale := range cmaes.scales {
+		largest = math.Max(largest, scale)
+		smallest = math.Min(smallest, scale)
+	}
+	if cmaes.sigma*largest < 1e-12 || largest/smallest > 1e7 {
+		return true
+	}
+
+	window := 10 + int(math.Ceil(30*float64(len(cmaes.mean))/float64(cmaes.lambda)))
+	if len(cmaes.recentBest) < window {
+		return false
+	}
+
+	recent := cmaes.recentBest[len(cmaes.recentBest)-window:]
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, fitness := range recent {
+		lowest = math.Min(lowest, fitness)
+		highest = math.Max(highest, fitness)
+	}
+
+	if highest-lowest < 1e-12 {
+		return true
+	}
+
+	// the best fitness is noisy, so the medians of the first and last 30% of the window are compared, not two samples
+	part := int(math.Ceil(0.3 * float64(window)))
+
+	return median(recent[len(recent)-part:]) <= median(recent[:part])
+}
+
+// median of values, leaving them in their order
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	return sorted[len(sorted)/2]
+}
+
+// restart begins a new run from a random network, with the population and step size picked by the restart strategy
+func (cmaes *CMAES) restart() {
+	if cmaes.runIsSmall {
+		cmaes.smallEvaluations += cmaes.runEvaluations
+	} else {
+		cmaes.largeEvaluations += cmaes.runEvaluations
+	}
+	cmaes.restarts++
+
+	start := newRandomNetwork(cmaes.spec).parameters
+	doubled := cmaes.largeLambda * 2
+	if doubled > cmaes.config.MaxPopulationSize {
+		doubled = cmaes.config.MaxPopulationSize
+	}
+
+	if cmaes.config.Restart == BIPOP && cmaes.restarts > 1 && cmaes.smallEvaluations < cmaes.largeEvaluations {
+		// a short local search, with a population somewhere between the default and the large one
+		uniform := rand.Float64()
+		lambda := int(float64(cmaes.defaultLambda) * math.Pow(0.5*float64(cmaes.largeLambda)/float64(cmaes.defaultLambda), uniform*uniform))
+		if lambda < 4 {
+			lambda = 4
+		}
+		cmaes.runIsSmall = true
+		cmaes.startRun(start, cmaes.config.Sigma*math.Pow(10, -2*rand.Float64()), lambda)
+		return
+	}
+
+	cmaes.largeLambda = doubled
+	cmaes.runIsSmall = false
+	cmaes.startRun(start, cmaes.config.Sigma, cmaes.largeLambda)
+}
+
+// savedCandidate is how a CMAES candidate is stored in a checkpoint
+type savedCandidate struct {
+	Parameters []float64 `json:"parameters"`
+	Step       []float64 `json:"step"`
+	Fitness    float64   `json:"fitness"`
+}
+
+// cmaesCheckpoint is the state of a CMAES run in a checkpoint, the constants of the current run are worked out
+// again from its population size
+type cmaesCheckpoint struct {
+	Generation    int              `json:"generation"`
+	Evaluations   int              `json:"evaluations"`
+	Lambda        int              `json:"lambda"`
+	Mean          []float64        `json:"mean"`
+	Sigma         float64          `json:"sigma"`
+	Covariance    [][]float64      `json:"covariance"`
+	Eigenvectors  [][]float64      `json:"eigenvectors"`
+	Scales        []float64        `json:"scales"`
+	PathC         []float64        `json:"pathC"`
+	PathSigma     []float64        `json:"pathSigma"`
+	StaleEigen    int              `json:"staleEigen"`
+	RunGeneration int              `json:"runGeneration"`
+	RecentBest    []float64        `json:"recentBest"`
+	Candidates    []savedCandidate `json:"candidates,omitempty"`
+	Best          *savedCandidate  `json:"best,omitempty"`
+
+	Restarts          int  `json:"restarts"`
+	LargeLambda       int  `json:"largeLambda"`
+	LargeEvaluations  int  `json:"largeEvaluations"`
+	SmallEvaluations  int  `json:"smallEvaluations"`
+	RunEvaluations    int  `json:"runEvaluations"`
+	RunIsSmall        bool `json:"runIsSmall"`
+	EvaluationsAtBest int  `json:"evaluationsAtBest"`
+}
+
+func saveCandidate(candidate *cmaesCandidate) savedCandidate {
+	return savedCandidate{Parameters: candidate.parameters, Step: candidate.step, Fitness: candidate.fitness}
+}
+
+func (saved savedCandidate) load(n int) (*cmaesCandidate, error) {
+	if len(saved.Parameters) != n || len(saved.Step) != n {
+		return nil, fmt.Errorf("candidate doesn't have %d parameters", n)
+	}
+
+	return &cmaesCandidate{parameters: saved.Parameters, step: saved.Step, fitness: saved.Fitness}, nil
+}
+
+// Checkpoint writes the distribution, the last generation and the restart bookkeeping to w as JSON
+func (cmaes *CMAES) Checkpoint(w io.Writer) error {
+	checkpoint := cmaesCheckpoint{
+		Generation:        cmaes.generationNumber,
+		Evaluations:       cmaes.evaluations,
+		Lambda:            cmaes.lambda,
+		Mean:              cmaes.mean,
+		Sigma:             cmaes.sigma,
+		Covariance:        cmaes.covariance,
+		Eigenvectors:      cmaes.eigenvectors,
+		Scales:            cmaes.scales,
+		PathC:             cmaes.pathC,
+		PathSigma:         cmaes.pathSigma,
+		StaleEigen:        cmaes.staleEigen,
+		RunGeneration:     cmaes.runGeneration,
+		RecentBest:        cmaes.recentBest,
+		Restarts:          cmaes.restarts,
+		LargeLambda:       cmaes.largeLambda,
+		LargeEvaluations:  cmaes.largeEvaluations,
+		SmallEvaluations:  cmaes.smallEvaluations,
+		RunEvaluations:    cmaes.runEvaluations,
+		RunIsSmall:        cmaes.runIsSmall,
+		EvaluationsAtBest: cmaes.evaluationsAtBest,
+	}
+	for _, candidate := range cmaes.candidates {
+		checkpoint.Candidates = append(checkpoint.Candidates, saveCandidate(candidate))
+	}
+	if cmaes.best != nil {
+		best := saveCandidate(cmaes.best)
+		checkpoint.Best = &best
+	}
+
+	return writeCheckpoint(w, checkpoint)
+}
+
+// Restore continues from a checkpoint written by Checkpoint of a run with the same spec and config
+func (cmaes *CMAES) Restore(r io.Reader) error {
+	var checkpoint cmaesCheckpoint
+	if err := readCheckpoint(r, &checkpoint); err != nil {
+		return err
+	}
+
+	n := cmaes.network.ParameterCount()
+	if len(checkpoint.Mean) != n || len(checkpoint.Scales) != n || len(checkpoint.PathC) != n ||
+		len(checkpoint.PathSigma) != n || len(checkpoint.Covariance) != n || len(checkpoint.Eigenvectors) != n {
+		return fmt.Errorf("checkpoint doesn't have %d parameters", n)
+	}
+	for i := 0; i < n; i++ {
+		if len(checkpoint.Covariance[i]) != n || len(checkpoint.Eigenvectors[i]) != n {
+			return fmt.Errorf("checkpoint matrices aren't %d by %d", n, n)
+		}
+	}
+	if checkpoint.Lambda < 4 || checkpoint.Candidates != nil && len(checkpoint.Candidates) != checkpoint.Lambda {
+		return fmt.Errorf("checkpoint has %d candidates for a population of %d", len(checkpoint.Candidates), checkpoint.Lambda)
+	}
+
+	var candidates []*cmaesCandidate
+	for _, saved := range checkpoint.Candidates {
+		candidate, err := saved.load(n)
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, candidate)
+	}
+	var best *cmaesCandidate
+	if checkpoint.Best != nil {
+		var err error
+		if best, err = checkpoint.Best.load(n); err != nil {
+			return err
+		}
+	}
+
+	cmaes.startRun(checkpoint.Mean, checkpoint.Sigma, checkpoint.Lambda)
+	cmaes.covariance = checkpoint.Covariance
+	cmaes.eigenvectors = checkpoint.Eigenvectors
+	cmaes.scales = checkpoint.Scales
+	cmaes.pathC = checkpoint.PathC
+	cmaes.pathSigma = checkpoint.PathSigma
+	cmaes.staleEigen = checkpoint.StaleEigen
+	cmaes.runGeneration = checkpoint.RunGeneration
+	cmaes.recentBest = checkpoint.RecentBest
+	cmaes.candidates = candidates
+	cmaes.best = best
+
+	cmaes.generationNumber = checkpoint.Generation
+	cmaes.evaluations = checkpoint.Evaluations
+	cmaes.restarts = checkpoint.Restarts
+	cmaes.largeLambda = checkpoint.LargeLambda
+	cmaes.largeEvaluations = checkpoint.LargeEvaluations
+	cmaes.smallEvaluations = checkpoint.SmallEvaluations
+	cmaes.runEvaluations = checkpoint.RunEvaluations
+	cmaes.runIsSmall = checkpoint.RunIsSmall
+	cmaes.evaluationsAtBest = checkpoint.EvaluationsAtBest
+
+	return nil
+}
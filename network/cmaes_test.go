@@ -0,0 +1,86 @@
+package network
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSymmetricEigen(t *testing.T) {
+	matrix := [][]float64{{4, 1, 2}, {1, 3, 0}, {2, 0, 5}}
+	values, vectors := symmetricEigen(matrix)
+
+	// V * diag(values) * V^T should give the matrix back
+	for i := range matrix {
+		for j := range matrix {
+			value := 0.0
+			for k := range values {
+				value += vectors[i][k] * values[k] * vectors[j][k]
+			}
+			if math.Abs(value-matrix[i][j]) > 1e-9 {
+				t.Fatalf("element %d,%d rebuilt as %v, want %v", i, j, value, matrix[i][j])
+			}
+		}
+	}
+}
+
+func TestCMAESMinimizesSphere(t *testing.T) {
+	spec := Spec{Sizes: []int{3, 1}, Activations: []Activation{{Function: Identity}}}
+	config := DefaultCMAESConfig()
+	config.Games = 1
+	config.Restart = NoRestart
+	cmaes := NewCMAES(spec, config, nil)
+	// fitness is how close the parameters are to 0.5
+	cmaes.evaluate = func(FeedForward) float64 {
+		distance := 0.0
+		for _, value := range cmaes.network.parameters {
+			distance += (value - 0.5) * (value - 0.5)
+		}
+		return -distance
+	}
+
+	for generation := 0; generation < 150; generation++ {
+		cmaes.EvaluateGeneration()
+		cmaes.EvolveGeneration()
+	}
+	cmaes.EvaluateGeneration()
+
+	if fitness := cmaes.candidates[0].fitness; fitness < -1e-6 {
+		t.Errorf("best fitness after 150 generations is %v", fitness)
+	}
+}
+
+func TestCMAESRestartsGrowPopulation(t *testing.T) {
+	config := DefaultCMAESConfig()
+	config.Restart = IPOP
+	cmaes := NewCMAES(DefaultSpec(2, 1), config, func(FeedForward) float64 { return 0 })
+	lambda := cmaes.lambda
+
+	cmaes.restart()
+	if cmaes.lambda != 2*lambda || cmaes.restarts != 1 {
+		t.Errorf("IPOP restart went from %d to %d candidates", lambda, cmaes.lambda)
+	}
+}
+
+func TestCMAESStagnation(t *testing.T) {
+	cmaes := NewCMAES(DefaultSpec(2, 1), DefaultCMAESConfig(), func(FeedForward) float64 { return 0 })
+	window := 10 + int(math.Ceil(30*float64(len(cmaes.mean))/float64(cmaes.lambda)))
+
+	// improving, but the last generation happens to be worse than the first
+	cmaes.recentBest = make([]float64, window)
+	for i := range cmaes.recentBest {
+		cmaes.recentBest[i] = float64(i)
+	}
+	cmaes.recentBest[window-1] = -1
+	if cmaes.converged() {
+		t.Error("an improving run with one bad generation converged")
+	}
+
+	// noise around the same fitness, with the last generation happening to be better than the first
+	for i := range cmaes.recentBest {
+		cmaes.recentBest[i] = float64(i * 5 % 7)
+	}
+	cmaes.recentBest[0], cmaes.recentBest[window-1] = 0, 6
+	if !cmaes.converged() {
+		t.Error("a run that stopped improving didn't converge")
+	}
+}
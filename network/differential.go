@@ -0,0 +1,356 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// DEStrategy is how differential evolution builds the mutant vector for each individual
+type DEStrategy int
+
+const (
+	// DERand1Bin adds the scaled difference of two random individuals to a third random one
+	DERand1Bin DEStrategy = iota
+	// DEBest1Bin adds the scaled difference of two random individuals to the best one
+	DEBest1Bin
+	// JADE moves each individual towards one of the best few, with F and CR adapted from the ones that worked
+	// and an archive of replaced individuals for the differences
+	JADE
+)
+
+const (
+	// share of the population JADE picks the target of each move from
+	jadeBestShare = 0.1
+	// how fast JADE's mean F and CR follow the successful ones
+	jadeAdaptation = 0.1
+)
+
+// DifferentialEvolution evolves a population by mutating each individual with the differences between others,
+// a child only replaces its parent if it does at least as well
+type DifferentialEvolution struct {
+	populationSize int
+	spec           Spec
+	strategy       DEStrategy
+	population     []*individual
+	// one child per individual, waiting to be evaluated, nil before the first generation is evaluated
+	trials []*individual
+
+	generationNumber int
+	// whether the population or the trials have been evaluated since the last EvolveGeneration, for Step
+	evaluated bool
+
+	// differential weight and crossover rate, JADE's starting means
+	f, cr float64
+	// the F and CR each trial was made with, and JADE's archive of individuals that were replaced
+	trialF, trialCR []float64
+	archive         []*individual
+
+	evaluate evaluateIndividual
+}
+
+func NewDifferentialEvolution(populationSize int, spec Spec, strategy DEStrategy, f, cr float64, evaluate evaluateIndividual) *DifferentialEvolution {
+	if err := spec.validate(); err != nil {
+		panic(err)
+	}
+	if populationSize < 4 {
+		panic("Differential evolution needs a population of at least 4")
+	}
+
+	de := &DifferentialEvolution{
+		populationSize: populationSize,
+		spec:           spec,
+		strategy:       strategy,
+		f:              f,
+		cr:             cr,
+		evaluate:       evaluate,
+	}
+
+	de.population = make([]*individual, populationSize)
+	for i := range de.population {
+		de.population[i] = newIndividual(spec)
+	}
+
+	return de
+}
+
+func (de *DifferentialEvolution) evaluateIndividual(individual *individual, fitnessTrack []float64) {
+	individual.fitness = medianFitness(de.evaluate, individual.feedForward, fitnessTrack)
+}
+
+// EvaluateGeneration evaluates the trials and replaces every individual whose trial did at least as well,
+// the first time round it evaluates the starting population
+func (de *DifferentialEvolution) EvaluateGeneration() {
+	fitnessTrack := make([]float64, 5)
+
+	if de.trials == nil {
+		for _, individual := range de.population {
+			de.evaluateIndividual(individual, fitnessTrack)
+		}
+		return
+	}
+
+	var successfulF, successfulCR []float64
+	for i, trial := range de.trials {
+		de.evaluateIndividual(trial, fitnessTrack)
+		if trial.fitness < de.population[i].fitness {
+			continue
+		}
+
+		if de.strategy == JADE {
+			de.archive = append(de.archive, de.population[i])
+			successfulF = append(successfulF, de.trialF[i])
+			successfulCR = append(successfulCR, de.trialCR[i])
+		}
+		de.population[i] = trial
+	}
+
+	if de.strategy == JADE {
+		de.adapt(successfulF, successfulCR)
+	}
+}
+
+// adapt moves JADE's means towards the F and CR of the trials that replaced their parents and trims the archive
+func (de *DifferentialEvolution) adapt(successfulF, successfulCR []float64) {
+	if len(successfulF) > 0 {
+		meanCR, sumF, sumSquaresF := 0.0, 0.0, 0.0
+		for i := range successfulF {
+			meanCR += successfulCR[i] / float64(len(successfulCR))
+			sumF += successfulF[i]
+			sumSquaresF += successfulF[i] * successfulF[i]
+		}
+
+		de.cr = (1-jadeAdaptation)*de.cr + jadeAdaptation*meanCR
+		// the Lehmer mean leans towards larger F, which keeps the steps from shrinking too fast
+		de.f = (1-jadeAdaptation)*de.f + jadeAdaptation*sumSquaresF/sumF
+	}
+
+	for len(de.archive) > de.populationSize {
+		last := len(de.archive) - 1
+		index := rand.Intn(len(de.archive))
+		de.archive[index] = de.archive[last]
+		de.archive = de.archive[:last]
+	}
+}
+
+func (de *DifferentialEvolution) bestIndividual() *individual {
+	best := de.population[0]
+
+	for _, individual := range de.population {
+		if individual.fitness > best.fitness {
+			best = individual
+		}
+	}
+
+	return best
+}
+
+// GetBestIndividual prints the stats of the last evaluated generation and returns its best individual
+func (de *DifferentialEvolution) GetBestIndividual() FeedForward {
+	fmt.Print(de.Stats())
+
+	return de.Best()
+}
+
+func (de *DifferentialEvolution) Best() FeedForward {
+	return de.bestIndividual().newFeedForward()
+}
+
+// Stats has JADE's current F and CR as details
+func (de *DifferentialEvolution) Stats() Stats {
+	stats := Stats{Generation: de.generationNumber, BestFitness: de.bestIndividual().fitness}
+	if de.strategy == JADE {
+		stats.Details = []Detail{detail("F", "%.3f", de.f), detail("CR", "%.3f", de.cr)}
+	}
+
+	return stats
+}
+
+// Step builds trials from the last evaluated generation, unless it hasn't been evaluated yet, and evaluates them
+func (de *DifferentialEvolution) Step() {
+	if de.evaluated {
+		de.EvolveGeneration()
+	}
+	de.EvaluateGeneration()
+	de.evaluated = true
+}
+
+// differentialCheckpoint is the state of a DifferentialEvolution run in a checkpoint
+type differentialCheckpoint struct {
+	Generation int               `json:"generation"`
+	Evaluated  bool              `json:"evaluated"`
+	Population []savedIndividual `json:"population"`
+	Trials     []savedIndividual `json:"trials,omitempty"`
+	F          float64           `json:"f"`
+	CR         float64           `json:"cr"`
+	TrialF     []float64         `json:"trialF,omitempty"`
+	TrialCR    []float64         `json:"trialCR,omitempty"`
+	Archive    []savedIndividual `json:"archive,omitempty"`
+}
+
+// Checkpoint writes the population, the trials and JADE's state to w as JSON
+func (de *DifferentialEvolution) Checkpoint(w io.Writer) error {
+	return writeCheckpoint(w, differentialCheckpoint{
+		Generation: de.generationNumber,
+		Evaluated:  de.evaluated,
+		Population: saveIndividuals(de.population),
+		Trials:     saveIndividuals(de.trials),
+		F:          de.f,
+		CR:         de.cr,
+		TrialF:     de.trialF,
+		TrialCR:    de.trialCR,
+		Archive:    saveIndividuals(de.archive),
+	})
+}
+
+// Restore continues from a checkpoint written by Checkpoint of a run with the same population size and spec
+func (de *DifferentialEvolution) Restore(r io.Reader) error {
+	var checkpoint differentialCheckpoint
+	if err := readCheckpoint(r, &checkpoint); err != nil {
+		return err
+	}
+	if len(checkpoint.Population) != de.populationSize {
+		return fmt.Errorf("checkpoint has a population of %d, not %d", len(checkpoint.Population), de.populationSize)
+	}
+	if checkpoint.Trials != nil && (len(checkpoint.Trials) != de.populationSize ||
+		len(checkpoint.TrialF) != de.populationSize || len(checkpoint.TrialCR) != de.populationSize) {
+		return fmt.Errorf("checkpoint doesn't have %d trials", de.populationSize)
+	}
+
+	population, err := loadIndividuals(de.spec, checkpoint.Population)
+	if err != nil {
+		return err
+	}
+	var trials []*individual
+	if checkpoint.Trials != nil {
+		if trials, err = loadIndividuals(de.spec, checkpoint.Trials); err != nil {
+			return err
+		}
+	}
+	archive, err := loadIndividuals(de.spec, checkpoint.Archive)
+	if err != nil {
+		return err
+	}
+	for _, individuals := range [][]*individual{population, trials, archive} {
+		for _, individual := range individuals {
+			if individual == nil {
+				return errors.New("checkpoint has an empty individual")
+			}
+		}
+	}
+
+	de.population = population
+	de.trials = trials
+	de.archive = archive
+	de.f, de.cr = checkpoint.F, checkpoint.CR
+	de.trialF, de.trialCR = checkpoint.TrialF, checkpoint.TrialCR
+	de.generationNumber = checkpoint.Generation
+	de.evaluated = checkpoint.Evaluated
+
+	return nil
+}
+
+// BestNetwork is a copy of the best network of the last evaluated generation
+func (de *DifferentialEvolution) BestNetwork() *Network {
+	return de.bestIndividual().Clone()
+}
+
+// SaveBest writes the best individual of the last evaluated generation to path as JSON
+func (de *DifferentialEvolution) SaveBest(path string) error {
+	return de.bestIndividual().Save(path)
+}
+
+// pick returns a random index that isn't in excluded
+func pick(n int, excluded ...int) int {
+	for {
+		index := rand.Intn(n)
+		taken := false
+		for _, other := range excluded {
+			if index == other {
+				taken = true
+			}
+		}
+		if !taken {
+			return index
+		}
+	}
+}
+
+// EvolveGeneration builds a trial for every individual from a mutant vector and binomial crossover
+func (de *DifferentialEvolution) EvolveGeneration() {
+	best := de.bestIndividual()
+	ranked := append([]*individual(nil), de.population...)
+	if de.strategy == JADE {
+		sort.Slice(ranked, func(i, j int) bool {
+			return ranked[i].fitness > ranked[j].fitness
+		})
+	}
+
+	de.trials = make([]*individual, de.populationSize)
+	de.trialF = make([]float64, de.populationSize)
+	de.trialCR = make([]float64, de.populationSize)
+	mutant := make([]float64, len(best.parameters))
+
+	for i, target := range de.population {
+		f, cr := de.f, de.cr
+		if de.strategy == JADE {
+			f, cr = jadeF(de.f), math.Max(0, math.Min(1, de.cr+0.1*rand.NormFloat64()))
+		}
+		de.trialF[i], de.trialCR[i] = f, cr
+
+		switch de.strategy {
+		case DERand1Bin:
+			r1 := pick(de.populationSize, i)
+			r2 := pick(de.populationSize, i, r1)
+			r3 := pick(de.populationSize, i, r1, r2)
+			for j := range mutant {
+				mutant[j] = de.population[r1].parameters[j] + f*(de.population[r2].parameters[j]-de.population[r3].parameters[j])
+			}
+		case DEBest1Bin:
+			r1 := pick(de.populationSize, i)
+			r2 := pick(de.populationSize, i, r1)
+			for j := range mutant {
+				mutant[j] = best.parameters[j] + f*(de.population[r1].parameters[j]-de.population[r2].parameters[j])
+			}
+		case JADE:
+			// current-to-pbest/1, the second individual of the difference can come from the archive
+			pbest := ranked[rand.Intn(int(math.Max(1, math.Ceil(jadeBestShare*float64(de.populationSize)))))]
+			r1 := pick(de.populationSize, i)
+			r2 := pick(de.populationSize+len(de.archive), i, r1)
+			other := de.population
+			if r2 >= de.populationSize {
+				other, r2 = de.archive, r2-de.populationSize
+			}
+			for j := range mutant {
+				mutant[j] = target.parameters[j] + f*(pbest.parameters[j]-target.parameters[j]) + f*(de.population[r1].parameters[j]-other[r2].parameters[j])
+			}
+		}
+
+		// binomial crossover, at least one parameter always comes from the mutant
+		trial := newEmptyIndividual(de.spec)
+		forced := rand.Intn(len(mutant))
+		for j := range mutant {
+			if j == forced || rand.Float64() < cr {
+				trial.parameters[j] = mutant[j]
+			} else {
+				trial.parameters[j] = target.parameters[j]
+			}
+		}
+		de.trials[i] = trial
+	}
+
+	de.generationNumber++
+}
+
+// jadeF draws an F from a Cauchy distribution around the mean, drawing again below 0 and capping it at 1
+func jadeF(mean float64) float64 {
+	for {
+		f := mean + 0.1*math.Tan(math.Pi*(rand.Float64()-0.5))
+		if f > 0 {
+			return math.Min(f, 1)
+		}
+	}
+}
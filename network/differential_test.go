@@ -0,0 +1,42 @@
+package network
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDifferentialEvolutionStrategies(t *testing.T) {
+	spec := Spec{Sizes: []int{2, 1}, Activations: []Activation{{Function: Identity}}}
+	evaluate := func(feedForward FeedForward) float64 {
+		return -math.Abs(feedForward([]float64{1, 1})[0] - 2)
+	}
+
+	for _, strategy := range []DEStrategy{DERand1Bin, DEBest1Bin, JADE} {
+		de := NewDifferentialEvolution(20, spec, strategy, 0.5, 0.9, evaluate)
+		de.EvaluateGeneration()
+		start := de.bestIndividual().fitness
+
+		previous := make([]float64, len(de.population))
+		for generation := 0; generation < 60; generation++ {
+			for i, individual := range de.population {
+				previous[i] = individual.fitness
+			}
+			de.EvolveGeneration()
+			de.EvaluateGeneration()
+
+			// selection never lets an individual get worse
+			for i, individual := range de.population {
+				if individual.fitness < previous[i] {
+					t.Fatalf("strategy %d: individual %d went from %v to %v", strategy, i, previous[i], individual.fitness)
+				}
+			}
+		}
+
+		if best := de.bestIndividual().fitness; best < start || best < -0.01 {
+			t.Errorf("strategy %d: best fitness went from %v to %v", strategy, start, best)
+		}
+		if strategy == JADE && len(de.archive) > 20 {
+			t.Errorf("JADE's archive grew to %d", len(de.archive))
+		}
+	}
+}
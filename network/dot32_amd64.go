@@ -0,0 +1,16 @@
+//go:build amd64 && !purego
+
+package network
+
+// dot32SSE is implemented in dot32_amd64.s, b must be at least as long as a
+//
+//go:noescape
+func dot32SSE(a, b []float32) float32
+
+func dot32(a, b []float32) float32 {
+	if len(b) < len(a) {
+		panic("dot32: b is shorter than a")
+	}
+
+	return dot32SSE(a, b)
+}
@@ -0,0 +1,65 @@
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+// func dot32SSE(a, b []float32) float32
+TEXT ·dot32SSE(SB), NOSPLIT, $0-52
+	MOVQ a_base+0(FP), SI
+	MOVQ b_base+24(FP), DI
+	MOVQ a_len+8(FP), CX
+	XORPS X0, X0
+	XORPS X1, X1
+
+	// 8 floats at a time into two accumulators
+loop8:
+	CMPQ CX, $8
+	JLT  loop4
+	MOVUPS (SI), X2
+	MOVUPS (DI), X3
+	MULPS X3, X2
+	ADDPS X2, X0
+	MOVUPS 16(SI), X4
+	MOVUPS 16(DI), X5
+	MULPS X5, X4
+	ADDPS X4, X1
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $8, CX
+	JMP  loop8
+
+loop4:
+	ADDPS X1, X0
+	CMPQ CX, $4
+	JLT  reduce
+	MOVUPS (SI), X2
+	MOVUPS (DI), X3
+	MULPS X3, X2
+	ADDPS X2, X0
+	ADDQ $16, SI
+	ADDQ $16, DI
+	SUBQ $4, CX
+
+	// add the 4 lanes together into the lowest one
+reduce:
+	MOVAPS X0, X1
+	SHUFPS $0x4E, X1, X1
+	ADDPS X1, X0
+	MOVAPS X0, X1
+	SHUFPS $0xB1, X1, X1
+	ADDPS X1, X0
+
+	// the last 0-3 floats one at a time
+tail:
+	CMPQ CX, $0
+	JEQ  done
+	MOVSS (SI), X2
+	MULSS (DI), X2
+	ADDSS X2, X0
+	ADDQ $4, SI
+	ADDQ $4, DI
+	DECQ CX
+	JMP  tail
+
+done:
+	MOVSS X0, ret+48(FP)
+	RET
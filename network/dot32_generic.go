@@ -0,0 +1,7 @@
+//go:build !amd64 || purego
+
+package network
+
+func dot32(a, b []float32) float32 {
+	return dot32Unrolled(a, b)
+}
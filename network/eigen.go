@@ -0,0 +1,69 @@
+package network
+
+import "math"
+
+// symmetricEigen decomposes a symmetric matrix into its eigenvalues and eigenvectors with cyclic Jacobi rotations,
+// column i of vectors belongs to values[i]. The matrix is left untouched.
+func symmetricEigen(matrix [][]float64) (values []float64, vectors [][]float64) {
+	n := len(matrix)
+	a := make([][]float64, n)
+	vectors = make([][]float64, n)
+	for i := range a {
+		a[i] = append([]float64(nil), matrix[i]...)
+		vectors[i] = make([]float64, n)
+		vectors[i][i] = 1
+	}
+
+	for sweep := 0; sweep < 100; sweep++ {
+		offDiagonal, diagonal := 0.0, 0.0
+		for i := 0; i < n; i++ {
+			diagonal += a[i][i] * a[i][i]
+			for j := i + 1; j < n; j++ {
+				offDiagonal += a[i][j] * a[i][j]
+			}
+		}
+		if offDiagonal <= 1e-30*diagonal || offDiagonal == 0 {
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+
+				// rotate rows and columns p and q so a[p][q] becomes 0
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := vectors[k][p], vectors[k][q]
+					vectors[k][p] = c*vkp - s*vkq
+					vectors[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	values = make([]float64, n)
+	for i := range values {
+		values[i] = a[i][i]
+	}
+
+	return values, vectors
+}
@@ -0,0 +1,288 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// EvolutionStrategyConfig holds the settings of an EvolutionStrategy, start from DefaultEvolutionStrategyConfig
+type EvolutionStrategyConfig struct {
+	// each pair is one noise vector tried added to and subtracted from the parameters
+	Pairs int
+	// standard deviation of the noise
+	Sigma float64
+	// Adam step size and decay rates
+	LearningRate float64
+	Beta1        float64
+	Beta2        float64
+	// share of the parameters taken off every step, keeps the weights from growing without end
+	WeightDecay float64
+	// goroutines the perturbations are evaluated on, the evaluation function must be safe to call concurrently
+	// when this is more than 1
+	Workers int
+}
+
+// DefaultEvolutionStrategyConfig uses the settings from OpenAI's evolution strategies paper scaled down to snake
+func DefaultEvolutionStrategyConfig() EvolutionStrategyConfig {
+	return EvolutionStrategyConfig{
+		Pairs:        100,
+		Sigma:        0.05,
+		LearningRate: 0.01,
+		Beta1:        0.9,
+		Beta2:        0.999,
+		WeightDecay:  0.005,
+		Workers:      runtime.NumCPU(),
+	}
+}
+
+func (config EvolutionStrategyConfig) validate() error {
+	if config.Pairs < 1 || config.Workers < 1 {
+		return errors.New("evolution strategies need at least one pair and one worker")
+	}
+	if config.Sigma <= 0 || config.LearningRate <= 0 {
+		return errors.New("evolution strategies need a sigma and learning rate above 0")
+	}
+
+	return nil
+}
+
+// EvolutionStrategy follows an estimate of the fitness gradient of a single network, made by trying random
+// perturbations of its parameters in both directions and weighting each by how the two compare
+type EvolutionStrategy struct {
+	config EvolutionStrategyConfig
+	center *Network
+
+	generationNumber int
+	// whether a generation has been evaluated since the last step, for Step
+	evaluated bool
+
+	evaluate evaluateIndividual
+
+	// noise vectors of the generation and the fitness of adding (even) and subtracting (odd) each of them
+	noise   [][]float64
+	fitness []float64
+	// fitness of the center itself, only for reporting
+	centerFitness float64
+
+	// Adam's moving averages of the gradient and its square
+	step   int
+	moment []float64
+	square []float64
+}
+
+func NewEvolutionStrategy(spec Spec, config EvolutionStrategyConfig, evaluate evaluateIndividual) *EvolutionStrategy {
+	if err := spec.validate(); err != nil {
+		panic(err)
+	}
+	if err := config.validate(); err != nil {
+		panic(err)
+	}
+
+	center := newRandomNetwork(spec)
+	noise := make([][]float64, config.Pairs)
+	for i := range noise {
+		noise[i] = make([]float64, center.ParameterCount())
+	}
+
+	return &EvolutionStrategy{
+		config:   config,
+		center:   center,
+		evaluate: evaluate,
+		noise:    noise,
+		fitness:  make([]float64, 2*config.Pairs),
+		moment:   make([]float64, center.ParameterCount()),
+		square:   make([]float64, center.ParameterCount()),
+	}
+}
+
+// EvaluateGeneration draws new noise and evaluates both directions of every perturbation, spread over the workers
+func (es *EvolutionStrategy) EvaluateGeneration() {
+	for _, noise := range es.noise {
+		for i := range noise {
+			noise[i] = rand.NormFloat64()
+		}
+	}
+
+	var wait sync.WaitGroup
+	for worker := 0; worker < es.config.Workers; worker++ {
+		wait.Add(1)
+		go func(worker int) {
+			defer wait.Done()
+
+			// every worker runs its own copy so the buffers aren't shared
+			network := es.center.Clone()
+			for perturbation := worker; perturbation < len(es.fitness); perturbation += es.config.Workers {
+				sign := 1.0
+				if perturbation%2 == 1 {
+					sign = -1
+				}
+
+				noise := es.noise[perturbation/2]
+				for i, value := range es.center.parameters {
+					network.parameters[i] = value + sign*es.config.Sigma*noise[i]
+				}
+
+				es.fitness[perturbation] = es.evaluate(network.feedForward)
+			}
+		}(worker)
+	}
+	wait.Wait()
+
+	es.centerFitness = medianFitness(es.evaluate, es.center.feedForward, make([]float64, 5))
+}
+
+// GetBestIndividual prints the stats of the last evaluated generation and returns the network being trained
+func (es *EvolutionStrategy) GetBestIndividual() FeedForward {
+	fmt.Print(es.Stats())
+
+	return es.Best()
+}
+
+// Best is a copy of the network being trained, so it can be watched while training goes on
+func (es *EvolutionStrategy) Best() FeedForward {
+	return es.center.Clone().newFeedForward()
+}
+
+// Stats has the fitness of the network being trained as the best fitness
+func (es *EvolutionStrategy) Stats() Stats {
+	return Stats{Generation: es.generationNumber, BestFitness: es.centerFitness}
+}
+
+// Step takes a step along the last estimated gradient, unless none has been estimated yet, and estimates a new one
+func (es *EvolutionStrategy) Step() {
+	if es.evaluated {
+		es.EvolveGeneration()
+	}
+	es.EvaluateGeneration()
+	es.evaluated = true
+}
+
+// evolutionStrategyCheckpoint is the state of an EvolutionStrategy in a checkpoint
+type evolutionStrategyCheckpoint struct {
+	Generation    int         `json:"generation"`
+	Evaluated     bool        `json:"evaluated"`
+	Center        []float64   `json:"center"`
+	CenterFitness float64     `json:"centerFitness"`
+	Noise         [][]float64 `json:"noise"`
+	Fitness       []float64   `json:"fitness"`
+	Step          int         `json:"step"`
+	Moment        []float64   `json:"moment"`
+	Square        []float64   `json:"square"`
+}
+
+// Checkpoint writes the network, the last generation's noise and Adam's state to w as JSON
+func (es *EvolutionStrategy) Checkpoint(w io.Writer) error {
+	return writeCheckpoint(w, evolutionStrategyCheckpoint{
+		Generation:    es.generationNumber,
+		Evaluated:     es.evaluated,
+		Center:        es.center.parameters,
+		CenterFitness: es.centerFitness,
+		Noise:         es.noise,
+		Fitness:       es.fitness,
+		Step:          es.step,
+		Moment:        es.moment,
+		Square:        es.square,
+	})
+}
+
+// Restore continues from a checkpoint written by Checkpoint of an optimizer with the same spec and number of pairs
+func (es *EvolutionStrategy) Restore(r io.Reader) error {
+	var checkpoint evolutionStrategyCheckpoint
+	if err := readCheckpoint(r, &checkpoint); err != nil {
+		return err
+	}
+
+	count := es.center.ParameterCount()
+	if len(checkpoint.Center) != count || len(checkpoint.Moment) != count || len(checkpoint.Square) != count {
+		return fmt.Errorf("checkpoint doesn't have %d parameters", count)
+	}
+	if len(checkpoint.Noise) != len(es.noise) || len(checkpoint.Fitness) != len(es.fitness) {
+		return fmt.Errorf("checkpoint doesn't have %d pairs", len(es.noise))
+	}
+	for _, noise := range checkpoint.Noise {
+		if len(noise) != count {
+			return fmt.Errorf("checkpoint noise doesn't have %d parameters", count)
+		}
+	}
+
+	if err := es.center.SetGenome(checkpoint.Center); err != nil {
+		return err
+	}
+	es.generationNumber = checkpoint.Generation
+	es.evaluated = checkpoint.Evaluated
+	es.centerFitness = checkpoint.CenterFitness
+	es.noise = checkpoint.Noise
+	es.fitness = checkpoint.Fitness
+	es.step = checkpoint.Step
+	es.moment = checkpoint.Moment
+	es.square = checkpoint.Square
+
+	return nil
+}
+
+// BestNetwork is a copy of the network being trained
+func (es *EvolutionStrategy) BestNetwork() *Network {
+	return es.center.Clone()
+}
+
+// SaveBest writes the network being trained to path as JSON
+func (es *EvolutionStrategy) SaveBest(path string) error {
+	return es.center.Save(path)
+}
+
+// centeredRanks replaces every fitness by its rank scaled to [-0.5, 0.5], so the step doesn't depend on the scale
+// of the fitness function and a few lucky games can't take over
+func centeredRanks(fitness []float64) []float64 {
+	order := make([]int, len(fitness))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return fitness[order[i]] < fitness[order[j]]
+	})
+
+	ranks := make([]float64, len(fitness))
+	if len(fitness) == 1 {
+		return ranks
+	}
+	for rank, index := range order {
+		ranks[index] = float64(rank)/float64(len(fitness)-1) - 0.5
+	}
+
+	return ranks
+}
+
+// EvolveGeneration moves the center along the estimated gradient with an Adam step, then decays it
+func (es *EvolutionStrategy) EvolveGeneration() {
+	ranks := centeredRanks(es.fitness)
+
+	es.step++
+	correction1 := 1 - math.Pow(es.config.Beta1, float64(es.step))
+	correction2 := 1 - math.Pow(es.config.Beta2, float64(es.step))
+	scale := 1 / (float64(len(es.fitness)) * es.config.Sigma)
+
+	for i := range es.center.parameters {
+		gradient := 0.0
+		for pair, noise := range es.noise {
+			gradient += (ranks[2*pair] - ranks[2*pair+1]) * noise[i]
+		}
+		gradient *= scale
+
+		es.moment[i] = es.config.Beta1*es.moment[i] + (1-es.config.Beta1)*gradient
+		es.square[i] = es.config.Beta2*es.square[i] + (1-es.config.Beta2)*gradient*gradient
+		step := es.config.LearningRate * (es.moment[i] / correction1) / (math.Sqrt(es.square[i]/correction2) + 1e-8)
+
+		// climbing the fitness, so the step is added
+		es.center.parameters[i] += step - es.config.LearningRate*es.config.WeightDecay*es.center.parameters[i]
+	}
+
+	// the float32 copy is out of date
+	es.center.buffers32 = nil
+	es.generationNumber++
+}
@@ -0,0 +1,38 @@
+package network
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCenteredRanks(t *testing.T) {
+	ranks := centeredRanks([]float64{10, -3, 1000, 4})
+	want := []float64{1.0 / 6, -0.5, 0.5, -1.0 / 6}
+	for i := range want {
+		if math.Abs(ranks[i]-want[i]) > 1e-12 {
+			t.Fatalf("ranks are %v, want %v", ranks, want)
+		}
+	}
+}
+
+func TestEvolutionStrategyClimbs(t *testing.T) {
+	// reward pushing the output up to 1, with identity outputs the fitness is smooth in the parameters
+	spec := Spec{Sizes: []int{2, 1}, Activations: []Activation{{Function: Identity}}}
+	config := DefaultEvolutionStrategyConfig()
+	config.Pairs = 20
+	config.LearningRate = 0.05
+	es := NewEvolutionStrategy(spec, config, func(feedForward FeedForward) float64 {
+		return -math.Abs(feedForward([]float64{0.5, -0.5})[0] - 1)
+	})
+
+	es.EvaluateGeneration()
+	start := es.centerFitness
+	for generation := 0; generation < 100; generation++ {
+		es.EvolveGeneration()
+		es.EvaluateGeneration()
+	}
+
+	if es.centerFitness <= start || es.centerFitness < -0.1 {
+		t.Errorf("fitness went from %v to %v", start, es.centerFitness)
+	}
+}
@@ -0,0 +1,110 @@
+package network
+
+// Precision is the floating point type networks are run with
+type Precision int
+
+const (
+	Float64 Precision = iota
+	// Float32 runs networks with float32 weights laid out row by row, roughly twice as many weights fit in cache
+	// and the dot products use SIMD on amd64
+	Float32
+)
+
+// network32 is a float32 copy of a network, the weights of each layer are stored row-major
+// (one row of input weights per output neuron) so every output is a single contiguous dot product
+type network32 struct {
+	weights [][]float32
+	biases  [][]float32
+	// activation function of each layer
+	functions []Activation
+
+	// reused buffers so running the network doesn't allocate
+	input       []float32
+	activations [][]float32
+	output      []float64
+}
+
+func newNetwork32(network *Network) *network32 {
+	n := &network32{
+		weights:     make([][]float32, len(network.weights)),
+		biases:      make([][]float32, len(network.biases)),
+		functions:   network.activations,
+		activations: make([][]float32, len(network.biases)),
+		input:       make([]float32, len(network.weights[0])/len(network.biases[0])),
+		output:      make([]float64, len(network.biases[len(network.biases)-1])),
+	}
+
+	for layer, weights := range network.weights {
+		outputSize := len(network.biases[layer])
+		inputSize := len(weights) / outputSize
+
+		// transpose from input-major to output-major
+		n.weights[layer] = make([]float32, len(weights))
+		for inputNeuronIndex := 0; inputNeuronIndex < inputSize; inputNeuronIndex++ {
+			for outputNeuronIndex := 0; outputNeuronIndex < outputSize; outputNeuronIndex++ {
+				n.weights[layer][outputNeuronIndex*inputSize+inputNeuronIndex] = float32(weights[inputNeuronIndex*outputSize+outputNeuronIndex])
+			}
+		}
+
+		n.biases[layer] = make([]float32, outputSize)
+		for outputNeuronIndex, bias := range network.biases[layer] {
+			n.biases[layer][outputNeuronIndex] = float32(bias)
+		}
+
+		n.activations[layer] = make([]float32, outputSize)
+	}
+
+	return n
+}
+
+// feedForward runs the network in float32, converting the input and output so it can be used as a FeedForward.
+// The returned slice is overwritten by the next call.
+func (n *network32) feedForward(input []float64) []float64 {
+	if len(input) != len(n.input) {
+		panic("Input size does not match network input size")
+	}
+
+	for i, value := range input {
+		n.input[i] = float32(value)
+	}
+
+	layerInput := n.input
+	for layer, weights := range n.weights {
+		output := n.activations[layer]
+		inputSize := len(layerInput)
+
+		for outputNeuronIndex := range output {
+			row := weights[outputNeuronIndex*inputSize : (outputNeuronIndex+1)*inputSize]
+			output[outputNeuronIndex] = dot32(row, layerInput) + n.biases[layer][outputNeuronIndex]
+		}
+
+		n.functions[layer].apply32(output)
+		layerInput = output
+	}
+
+	for i, value := range layerInput {
+		n.output[i] = float64(value)
+	}
+
+	return n.output
+}
+
+// dot32Unrolled is the pure Go dot product, unrolled 4 ways with separate sums so the additions can overlap
+func dot32Unrolled(a, b []float32) float32 {
+	b = b[:len(a)]
+
+	var sum0, sum1, sum2, sum3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		sum0 += a[i] * b[i]
+		sum1 += a[i+1] * b[i+1]
+		sum2 += a[i+2] * b[i+2]
+		sum3 += a[i+3] * b[i+3]
+	}
+
+	for ; i < len(a); i++ {
+		sum0 += a[i] * b[i]
+	}
+
+	return (sum0 + sum1) + (sum2 + sum3)
+}
@@ -0,0 +1,89 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func BenchmarkFeedForward32(b *testing.B) {
+	network := newNetwork32(NewNetwork(DefaultSpec(benchmarkSizes...)))
+	input := benchmarkInputs(1)[0]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		network.feedForward(input)
+	}
+}
+
+func TestDot32MatchesNaive(t *testing.T) {
+	for length := 0; length < 40; length++ {
+		a := make([]float32, length)
+		b := make([]float32, length+3)
+		for i := range b {
+			if i < length {
+				a[i] = rand.Float32()*2 - 1
+			}
+			b[i] = rand.Float32()*2 - 1
+		}
+
+		var naive float64
+		for i := range a {
+			naive += float64(a[i]) * float64(b[i])
+		}
+
+		for name, got := range map[string]float32{"dot32": dot32(a, b), "dot32Unrolled": dot32Unrolled(a, b)} {
+			if math.Abs(float64(got)-naive) > 1e-5 {
+				t.Errorf("%s of length %d: got %v, want %v", name, length, got, naive)
+			}
+		}
+	}
+}
+
+func TestFeedForward32MatchesFloat64(t *testing.T) {
+	specs := []Spec{DefaultSpec(benchmarkSizes...), DefaultSpec(3, 1), DefaultSpec(7, 5, 2), DefaultSpec(33, 17, 9, 3)}
+	for function := range activationNames {
+		specs = append(specs, Spec{Sizes: []int{9, 6, 4}, Activations: []Activation{{Function: function, Alpha: 0.2}, {Function: function, Alpha: 0.2}}})
+	}
+
+	for _, spec := range specs {
+		network := NewNetwork(spec)
+		network32 := newNetwork32(network)
+
+		for _, input := range benchmarkInputsOfSize(100, spec.Sizes[0]) {
+			want := append([]float64(nil), network.feedForward(input)...)
+			got := network32.feedForward(input)
+
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-4 {
+					t.Fatalf("spec %v output %d: float32 gave %v, float64 gave %v", spec, i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestFeedForward32DoesNotAllocate(t *testing.T) {
+	network := newNetwork32(NewNetwork(DefaultSpec(benchmarkSizes...)))
+	input := benchmarkInputs(1)[0]
+
+	allocs := testing.AllocsPerRun(100, func() {
+		network.feedForward(input)
+	})
+	if allocs != 0 {
+		t.Errorf("feedForward allocated %v times per run", allocs)
+	}
+}
+
+func benchmarkInputsOfSize(count, size int) [][]float64 {
+	inputs := make([][]float64, count)
+	for i := range inputs {
+		inputs[i] = make([]float64, size)
+		for j := range inputs[i] {
+			inputs[i][j] = rand.Float64()*2 - 1
+		}
+	}
+
+	return inputs
+}
@@ -1,16 +1,44 @@
 package network
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
+	"sort"
 )
 
 type FeedForward func(input []float64) []float64
 type evaluateIndividual func(FeedForward) float64
 
+// evaluateGroup plays the networks against each other and returns a fitness for each of them
+type evaluateGroup func([]FeedForward) []float64
+
+// Pairing decides who plays who when individuals are evaluated in groups
+type Pairing int
+
+const (
+	// RoundRobin plays every pair of individuals once, the number of games grows with the square of the population
+	RoundRobin Pairing = iota
+	// RandomPairing shuffles the population into groups each round
+	RandomPairing
+	// HallOfFamePairing plays each individual against a sample of the best individuals from earlier generations
+	HallOfFamePairing
+)
+
+// hallOfFameSize is how many past generation winners are kept to play against
+const hallOfFameSize = 50
+
+type groupEvaluation struct {
+	pairing   Pairing
+	groupSize int
+	rounds    int
+	evaluate  evaluateGroup
+}
+
 type GeneticAlgorithm struct {
 	populationSize int
-	sizes          []int
+	spec           Spec
 	population     []*individual
 
 	generationNumber int
@@ -19,12 +47,32 @@ type GeneticAlgorithm struct {
 	mutationRate   float64
 
 	evaluate evaluateIndividual
+
+	// when set, individuals are evaluated by playing against each other instead of alone
+	group      *groupEvaluation
+	hallOfFame []*individual
+
+	precision Precision
+
+	// when set, the population is split into species that share their fitness and breed on their own
+	niching *Niching
+	niches  []*niche
+
+	// when set, individuals are selected for the novelty of their behavior instead of their fitness
+	novelty *noveltySearch
+
+	// whether the population has been evaluated since it was made, for Step
+	evaluated bool
 }
 
-func NewGeneticAlgoritm(populationSize int, sizes []int, mutationChance, mutationRate float64, evaluate evaluateIndividual) *GeneticAlgorithm {
+func NewGeneticAlgoritm(populationSize int, spec Spec, mutationChance, mutationRate float64, evaluate evaluateIndividual) *GeneticAlgorithm {
+	if err := spec.validate(); err != nil {
+		panic(err)
+	}
+
 	ga := &GeneticAlgorithm{
 		populationSize: populationSize,
-		sizes:          sizes,
+		spec:           spec,
 		mutationChance: mutationChance,
 		mutationRate:   mutationRate,
 		evaluate:       evaluate,
@@ -38,43 +86,247 @@ func NewGeneticAlgoritm(populationSize int, sizes []int, mutationChance, mutatio
 func (ga *GeneticAlgorithm) generatePopulation() {
 	ga.population = make([]*individual, ga.populationSize)
 	for i := 0; i < ga.populationSize; i++ {
-		ga.population[i] = newIndividual(ga.sizes)
+		ga.population[i] = newIndividual(ga.spec)
+	}
+}
+
+// UseGroupEvaluation switches the algorithm to evaluating individuals against each other in groups of groupSize,
+// the fitness of an individual is its average over all the games it played. Round robin always plays pairs and ignores rounds.
+func (ga *GeneticAlgorithm) UseGroupEvaluation(pairing Pairing, groupSize, rounds int, evaluate evaluateGroup) {
+	if groupSize < 2 {
+		panic("Group evaluation needs groups of at least 2")
 	}
+	if rounds < 1 {
+		panic("Group evaluation needs at least 1 round")
+	}
+
+	ga.group = &groupEvaluation{
+		pairing:   pairing,
+		groupSize: groupSize,
+		rounds:    rounds,
+		evaluate:  evaluate,
+	}
+}
+
+// SetPrecision chooses whether networks are run in float64 or float32, evolution itself always stays in float64
+func (ga *GeneticAlgorithm) SetPrecision(precision Precision) {
+	ga.precision = precision
+}
+
+// feedForwardOf runs the individual with its own buffers at the chosen precision
+func (ga *GeneticAlgorithm) feedForwardOf(individual *individual) FeedForward {
+	if ga.precision == Float32 {
+		return individual.feedForward32
+	}
+
+	return individual.feedForward
+}
+
+// newFeedForward is feedForwardOf with new buffers, for networks handed out of the algorithm
+func (ga *GeneticAlgorithm) newFeedForward(individual *individual) FeedForward {
+	if ga.precision == Float32 {
+		return newNetwork32(individual.Network).feedForward
+	}
+
+	return individual.newFeedForward()
 }
 
 func (ga *GeneticAlgorithm) EvaluateGeneration() {
+	if ga.novelty != nil {
+		ga.evaluateNovelty()
+	} else if ga.group != nil {
+		ga.evaluateGroups()
+	} else {
+		ga.evaluateIndividuals()
+	}
+
+	if ga.niching != nil {
+		ga.speciate()
+	}
+}
+
+func (ga *GeneticAlgorithm) evaluateIndividuals() {
 	fitnessTrack := make([]float64, 5)
 
 	for _, individual := range ga.population {
-		for i := 0; i < len(fitnessTrack); i++ {
-			fitnessTrack[i] = ga.evaluate(individual.feedForward)
+		ga.evaluateIndividual(individual, fitnessTrack)
+	}
+}
+
+// evaluateIndividual plays a game for every entry of fitnessTrack and keeps the middle game's fitness. Unlike
+// medianFitness the games aren't sorted first, so the genetic algorithm, and niching and islands with it, scores
+// individuals the way it always has.
+func (ga *GeneticAlgorithm) evaluateIndividual(individual *individual, fitnessTrack []float64) {
+	feedForward := ga.feedForwardOf(individual)
+	for i := range fitnessTrack {
+		fitnessTrack[i] = ga.evaluate(feedForward)
+	}
+
+	individual.fitness = fitnessTrack[len(fitnessTrack)/2]
+}
+
+// medianFitness plays a game with the network for every entry of fitnessTrack and returns the median fitness, so one
+// lucky or unlucky game doesn't decide it. fitnessTrack is left holding every game's fitness in order.
+func medianFitness(evaluate evaluateIndividual, feedForward FeedForward, fitnessTrack []float64) float64 {
+	for i := range fitnessTrack {
+		fitnessTrack[i] = evaluate(feedForward)
+	}
+
+	sort.Float64s(fitnessTrack)
+
+	return fitnessTrack[len(fitnessTrack)/2]
+}
+
+func (ga *GeneticAlgorithm) evaluateGroups() {
+	scores := make([]float64, len(ga.population))
+	games := make([]int, len(ga.population))
+
+	// play runs one game, players with an index below 0 are opponents that aren't being scored
+	play := func(players []*individual, indices []int) {
+		feedForwards := make([]FeedForward, len(players))
+		for i, player := range players {
+			feedForwards[i] = ga.feedForwardOf(player)
+		}
+
+		results := ga.group.evaluate(feedForwards)
+		for i, index := range indices {
+			if index >= 0 {
+				scores[index] += results[i]
+				games[index]++
+			}
 		}
+	}
+
+	switch ga.group.pairing {
+	case RoundRobin:
+		for i := 0; i < len(ga.population); i++ {
+			for j := i + 1; j < len(ga.population); j++ {
+				play([]*individual{ga.population[i], ga.population[j]}, []int{i, j})
+			}
+		}
+	case RandomPairing:
+		for round := 0; round < ga.group.rounds; round++ {
+			order := rand.Perm(len(ga.population))
+			for start := 0; start < len(order); start += ga.group.groupSize {
+				players := make([]*individual, 0, ga.group.groupSize)
+				indices := make([]int, 0, ga.group.groupSize)
+				for i := start; i < start+ga.group.groupSize; i++ {
+					if i < len(order) {
+						players = append(players, ga.population[order[i]])
+						indices = append(indices, order[i])
+					} else {
+						// fill up the last group with random opponents
+						players = append(players, ga.population[rand.Intn(len(ga.population))])
+						indices = append(indices, -1)
+					}
+				}
+
+				play(players, indices)
+			}
+		}
+	case HallOfFamePairing:
+		// before there is a hall of fame the current population is the best we have
+		opponents := ga.hallOfFame
+		if len(opponents) == 0 {
+			opponents = ga.population
+		}
+
+		for i, player := range ga.population {
+			for round := 0; round < ga.group.rounds; round++ {
+				players := []*individual{player}
+				indices := []int{i}
+				for len(players) < ga.group.groupSize {
+					players = append(players, opponents[rand.Intn(len(opponents))])
+					indices = append(indices, -1)
+				}
+
+				play(players, indices)
+			}
+		}
+	}
+
+	for i, individual := range ga.population {
+		if games[i] > 0 {
+			individual.fitness = scores[i] / float64(games[i])
+		}
+	}
 
-		// median fitness
-		individual.fitness = fitnessTrack[len(fitnessTrack)/2]
+	ga.hallOfFame = append(ga.hallOfFame, ga.bestIndividual())
+	if len(ga.hallOfFame) > hallOfFameSize {
+		ga.hallOfFame = ga.hallOfFame[1:]
 	}
 }
 
-func (ga *GeneticAlgorithm) GetBestIndividual() FeedForward {
+func (ga *GeneticAlgorithm) bestIndividual() *individual {
 	best := ga.population[0]
 
 	for _, individual := range ga.population {
-		if individual.fitness > best.fitness {
+		if ga.performance(individual) > ga.performance(best) {
 			best = individual
 		}
 	}
 
-	// print generation and best fitness
-	fmt.Printf("Generation: %d, Fitness: %f", ga.generationNumber, best.fitness)
+	return best
+}
 
-	return best.feedForward
+// HallOfFame returns the best individuals of past generations when using group evaluation, oldest first
+func (ga *GeneticAlgorithm) HallOfFame() []FeedForward {
+	feedForwards := make([]FeedForward, len(ga.hallOfFame))
+	for i, individual := range ga.hallOfFame {
+		feedForwards[i] = ga.newFeedForward(individual)
+	}
+
+	return feedForwards
 }
 
-func (ga *GeneticAlgorithm) tournamentSelection(tournamentSize int) *individual {
-	best := ga.population[rand.Intn(len(ga.population))]
+// GetBestIndividual prints the stats of the last evaluated generation and returns its best network
+func (ga *GeneticAlgorithm) GetBestIndividual() FeedForward {
+	fmt.Print(ga.Stats())
+
+	return ga.Best()
+}
+
+func (ga *GeneticAlgorithm) Best() FeedForward {
+	return ga.newFeedForward(ga.bestIndividual())
+}
+
+func (ga *GeneticAlgorithm) Stats() Stats {
+	best := ga.bestIndividual()
+	stats := Stats{Generation: ga.generationNumber, BestFitness: ga.performance(best)}
+	if ga.novelty != nil {
+		stats.Details = append(stats.Details, ga.noveltyDetails(best)...)
+	}
+	if ga.niching != nil {
+		stats.Details = append(stats.Details, ga.speciesDetail())
+	}
+
+	return stats
+}
+
+// Step evolves the next generation, unless none has been evaluated yet, and evaluates it
+func (ga *GeneticAlgorithm) Step() {
+	if ga.evaluated {
+		ga.EvolveGeneration()
+	}
+	ga.EvaluateGeneration()
+	ga.evaluated = true
+}
+
+// BestNetwork is a copy of the best network of the last evaluated generation
+func (ga *GeneticAlgorithm) BestNetwork() *Network {
+	return ga.bestIndividual().Clone()
+}
+
+// GetBestIndividualBatch is GetBestIndividual for running many inputs through the best network at once
+func (ga *GeneticAlgorithm) GetBestIndividualBatch() BatchFeedForward {
+	return ga.bestIndividual().newBatchFeedForward()
+}
+
+func tournamentSelection(population []*individual, tournamentSize int) *individual {
+	best := population[rand.Intn(len(population))]
 
 	for i := 0; i < tournamentSize-1; i++ {
-		individual := ga.population[rand.Intn(len(ga.population))]
+		individual := population[rand.Intn(len(population))]
 		if individual.fitness > best.fitness {
 			best = individual
 		}
@@ -84,8 +336,13 @@ func (ga *GeneticAlgorithm) tournamentSelection(tournamentSize int) *individual
 }
 
 func (ga *GeneticAlgorithm) crossoverParents(parent1, parent2 *individual) *individual {
-	// TODO: newIndividual will generate random weights, performance boost possible if we fix that
-	child := newIndividual(ga.sizes)
+	return crossoverParents(ga.spec, parent1, parent2)
+}
+
+// crossoverParents picks every weight and bias of the child from either parent
+func crossoverParents(spec Spec, parent1, parent2 *individual) *individual {
+	// every weight is copied from a parent, so there's no need for random ones
+	child := newEmptyIndividual(spec)
 
 	for synapse := 0; synapse < len(parent1.weights); synapse++ {
 		for weightIndex := 0; weightIndex < len(parent1.weights[synapse]); weightIndex++ {
@@ -109,26 +366,37 @@ func (ga *GeneticAlgorithm) crossoverParents(parent1, parent2 *individual) *indi
 }
 
 func (ga *GeneticAlgorithm) mutateIndividual(individual *individual) {
+	mutateIndividual(individual, ga.mutationChance, ga.mutationRate)
+}
+
+// mutateIndividual nudges each weight and bias by up to mutationRate with a chance of mutationChance
+func mutateIndividual(individual *individual, mutationChance, mutationRate float64) {
 	for synapse := 0; synapse < len(individual.weights); synapse++ {
 		for weightIndex := 0; weightIndex < len(individual.weights[synapse]); weightIndex++ {
-			if rand.Float64() < ga.mutationChance {
-				individual.weights[synapse][weightIndex] += (rand.Float64()*2 - 1) * ga.mutationRate
+			if rand.Float64() < mutationChance {
+				individual.weights[synapse][weightIndex] += (rand.Float64()*2 - 1) * mutationRate
 			}
 		}
 
 		for biasIndex := 0; biasIndex < len(individual.biases[synapse]); biasIndex++ {
-			if rand.Float64() < ga.mutationChance {
-				individual.biases[synapse][biasIndex] += (rand.Float64()*2 - 1) * ga.mutationRate
+			if rand.Float64() < mutationChance {
+				individual.biases[synapse][biasIndex] += (rand.Float64()*2 - 1) * mutationRate
 			}
 		}
 	}
 }
 
 func (ga *GeneticAlgorithm) EvolveGeneration() {
+	if ga.niching != nil {
+		ga.evolveNiches()
+		ga.generationNumber++
+		return
+	}
+
 	newPopulation := make([]*individual, ga.populationSize)
 	for i := 0; i < ga.populationSize; i++ {
-		parent1 := ga.tournamentSelection(10)
-		parent2 := ga.tournamentSelection(10)
+		parent1 := tournamentSelection(ga.population, 10)
+		parent2 := tournamentSelection(ga.population, 10)
 		child := ga.crossoverParents(parent1, parent2)
 		ga.mutateIndividual(child)
 		newPopulation[i] = child
@@ -136,3 +404,113 @@ func (ga *GeneticAlgorithm) EvolveGeneration() {
 	ga.population = newPopulation
 	ga.generationNumber++
 }
+
+// geneticCheckpoint is the state of a GeneticAlgorithm in a checkpoint
+type geneticCheckpoint struct {
+	Generation int               `json:"generation"`
+	Evaluated  bool              `json:"evaluated"`
+	Population []savedIndividual `json:"population"`
+	HallOfFame []savedIndividual `json:"hallOfFame,omitempty"`
+	// species as indices into the population, and the threshold as it was adapted
+	Niches           []savedNiche `json:"niches,omitempty"`
+	NichingThreshold float64      `json:"nichingThreshold,omitempty"`
+	NoveltyArchive   [][]float64  `json:"noveltyArchive,omitempty"`
+}
+
+type savedNiche struct {
+	Representative savedIndividual `json:"representative"`
+	Members        []int           `json:"members"`
+}
+
+func (ga *GeneticAlgorithm) checkpoint() geneticCheckpoint {
+	checkpoint := geneticCheckpoint{
+		Generation: ga.generationNumber,
+		Evaluated:  ga.evaluated,
+		Population: saveIndividuals(ga.population),
+		HallOfFame: saveIndividuals(ga.hallOfFame),
+	}
+
+	if ga.niching != nil {
+		checkpoint.NichingThreshold = ga.niching.Threshold
+		index := make(map[*individual]int, len(ga.population))
+		for i, individual := range ga.population {
+			index[individual] = i
+		}
+		for _, existing := range ga.niches {
+			saved := savedNiche{Representative: saveIndividuals([]*individual{existing.representative})[0]}
+			for _, member := range existing.members {
+				saved.Members = append(saved.Members, index[member])
+			}
+			checkpoint.Niches = append(checkpoint.Niches, saved)
+		}
+	}
+	if ga.novelty != nil {
+		checkpoint.NoveltyArchive = ga.novelty.archive
+	}
+
+	return checkpoint
+}
+
+func (ga *GeneticAlgorithm) restore(checkpoint geneticCheckpoint) error {
+	population, err := loadIndividuals(ga.spec, checkpoint.Population)
+	if err != nil {
+		return err
+	}
+	if len(population) == 0 {
+		return errors.New("checkpoint has no population")
+	}
+	hallOfFame, err := loadIndividuals(ga.spec, checkpoint.HallOfFame)
+	if err != nil {
+		return err
+	}
+
+	var niches []*niche
+	for _, saved := range checkpoint.Niches {
+		representative, err := loadIndividuals(ga.spec, []savedIndividual{saved.Representative})
+		if err != nil {
+			return err
+		}
+
+		restored := &niche{representative: representative[0]}
+		for _, member := range saved.Members {
+			if member < 0 || member >= len(population) {
+				return fmt.Errorf("species member %d is outside the population", member)
+			}
+			restored.members = append(restored.members, population[member])
+		}
+		niches = append(niches, restored)
+	}
+	if ga.niching != nil && checkpoint.Evaluated && len(niches) == 0 {
+		return errors.New("checkpoint was made without niching")
+	}
+
+	ga.population = population
+	ga.populationSize = len(population)
+	ga.hallOfFame = hallOfFame
+	ga.niches = niches
+	ga.generationNumber = checkpoint.Generation
+	ga.evaluated = checkpoint.Evaluated
+	if ga.niching != nil && checkpoint.NichingThreshold > 0 {
+		ga.niching.Threshold = checkpoint.NichingThreshold
+	}
+	if ga.novelty != nil {
+		ga.novelty.archive = checkpoint.NoveltyArchive
+	}
+
+	return nil
+}
+
+// Checkpoint writes the population and everything the search has learned to w as JSON
+func (ga *GeneticAlgorithm) Checkpoint(w io.Writer) error {
+	return writeCheckpoint(w, ga.checkpoint())
+}
+
+// Restore continues from a checkpoint written by Checkpoint of an algorithm with the same settings
+func (ga *GeneticAlgorithm) Restore(r io.Reader) error {
+	var checkpoint geneticCheckpoint
+	if err := readCheckpoint(r, &checkpoint); err != nil {
+		return err
+	}
+
+	return ga.restore(checkpoint)
+}
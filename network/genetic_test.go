@@ -0,0 +1,38 @@
+package network
+
+import "testing"
+
+func TestMedianFitness(t *testing.T) {
+	// games come back out of order, the median is the middle one once sorted
+	games := []float64{9, 1, 4, 7, 2}
+	played := 0
+	evaluate := func(feedForward FeedForward) float64 {
+		played++
+		return games[played-1]
+	}
+
+	fitnessTrack := make([]float64, len(games))
+	if median := medianFitness(evaluate, nil, fitnessTrack); median != 4 {
+		t.Errorf("median of %v is %v, expected 4", games, median)
+	}
+	if played != len(games) {
+		t.Errorf("played %d games, expected %d", played, len(games))
+	}
+}
+
+func TestGeneticAlgorithmKeepsMiddleGame(t *testing.T) {
+	// every individual plays the same games in the same order, the genetic algorithm keeps the third one unsorted
+	games := []float64{9, 1, 2, 7, 4}
+	played := 0
+	ga := NewGeneticAlgoritm(3, DefaultSpec(1, 1), 0, 0, func(feedForward FeedForward) float64 {
+		played++
+		return games[(played-1)%len(games)]
+	})
+	ga.EvaluateGeneration()
+
+	for i, individual := range ga.population {
+		if individual.fitness != 2 {
+			t.Errorf("individual %d has fitness %v, expected the middle game's 2", i, individual.fitness)
+		}
+	}
+}
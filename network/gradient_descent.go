@@ -0,0 +1,117 @@
+package network
+
+import "math"
+
+// GradientOptimizer moves a network's parameters against the gradient of a loss, laid out like Genome. It keeps state
+// about the parameters between steps, so it belongs to one network.
+type GradientOptimizer interface {
+	Step(network *Network, gradient []float64)
+}
+
+var (
+	_ GradientOptimizer = (*SGD)(nil)
+	_ GradientOptimizer = (*Adam)(nil)
+	_ GradientOptimizer = (*RMSProp)(nil)
+)
+
+func checkGradient(network *Network, gradient []float64) {
+	if len(gradient) != len(network.parameters) {
+		panic("Gradient size does not match the network's parameter count")
+	}
+}
+
+// SGD is plain gradient descent, with momentum each step also keeps going in the direction of the last ones
+type SGD struct {
+	LearningRate float64
+	// share of the last step carried into the next, 0 for none
+	Momentum float64
+
+	velocity []float64
+}
+
+func NewSGD(learningRate, momentum float64) *SGD {
+	return &SGD{LearningRate: learningRate, Momentum: momentum}
+}
+
+func (sgd *SGD) Step(network *Network, gradient []float64) {
+	checkGradient(network, gradient)
+	if sgd.velocity == nil {
+		sgd.velocity = make([]float64, len(gradient))
+	}
+
+	for i, g := range gradient {
+		sgd.velocity[i] = sgd.Momentum*sgd.velocity[i] - sgd.LearningRate*g
+		network.parameters[i] += sgd.velocity[i]
+	}
+
+	// the float32 copy is out of date
+	network.buffers32 = nil
+}
+
+// Adam takes gradient descent steps scaled by running averages of the gradient and of its square, so every
+// parameter gets a step size suited to it
+type Adam struct {
+	LearningRate float64
+	// decay of the averages of the gradient and of its square
+	Beta1, Beta2 float64
+	// keeps the step finite for parameters whose gradient has always been 0
+	Epsilon float64
+
+	step           int
+	moment, square []float64
+}
+
+// NewAdam uses the usual betas of 0.9 and 0.999
+func NewAdam(learningRate float64) *Adam {
+	return &Adam{LearningRate: learningRate, Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8}
+}
+
+// Step moves the network's parameters against the gradient, which is laid out like Genome
+func (adam *Adam) Step(network *Network, gradient []float64) {
+	checkGradient(network, gradient)
+	if adam.moment == nil {
+		adam.moment = make([]float64, len(gradient))
+		adam.square = make([]float64, len(gradient))
+	}
+
+	adam.step++
+	// the averages start at 0, correct for how far they still lean towards it
+	correction1 := 1 - math.Pow(adam.Beta1, float64(adam.step))
+	correction2 := 1 - math.Pow(adam.Beta2, float64(adam.step))
+	for i, g := range gradient {
+		adam.moment[i] = adam.Beta1*adam.moment[i] + (1-adam.Beta1)*g
+		adam.square[i] = adam.Beta2*adam.square[i] + (1-adam.Beta2)*g*g
+		network.parameters[i] -= adam.LearningRate * (adam.moment[i] / correction1) / (math.Sqrt(adam.square[i]/correction2) + adam.Epsilon)
+	}
+
+	network.buffers32 = nil
+}
+
+// RMSProp divides each step by a running average of the size of the parameter's recent gradients
+type RMSProp struct {
+	LearningRate float64
+	// decay of the average of the squared gradient
+	Decay   float64
+	Epsilon float64
+
+	square []float64
+}
+
+// NewRMSProp uses a decay of 0.9
+func NewRMSProp(learningRate float64) *RMSProp {
+	return &RMSProp{LearningRate: learningRate, Decay: 0.9, Epsilon: 1e-8}
+}
+
+func (rmsProp *RMSProp) Step(network *Network, gradient []float64) {
+	checkGradient(network, gradient)
+	if rmsProp.square == nil {
+		rmsProp.square = make([]float64, len(gradient))
+	}
+
+	for i, g := range gradient {
+		rmsProp.square[i] = rmsProp.Decay*rmsProp.square[i] + (1-rmsProp.Decay)*g*g
+		network.parameters[i] -= rmsProp.LearningRate * g / (math.Sqrt(rmsProp.square[i]) + rmsProp.Epsilon)
+	}
+
+	network.buffers32 = nil
+}
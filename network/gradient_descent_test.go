@@ -0,0 +1,62 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGradientOptimizersFitFunction(t *testing.T) {
+	inputs := make([][]float64, 64)
+	targets := make([][]float64, 64)
+	for i := range inputs {
+		x, y := rand.Float64()*2-1, rand.Float64()*2-1
+		inputs[i] = []float64{x, y}
+		targets[i] = []float64{math.Sin(2*x) + 0.5*y}
+	}
+
+	optimizers := map[string]func() GradientOptimizer{
+		"sgd":       func() GradientOptimizer { return NewSGD(0.05, 0.9) },
+		"adam":      func() GradientOptimizer { return NewAdam(0.01) },
+		"rmsprop":   func() GradientOptimizer { return NewRMSProp(0.005) },
+		"sgd-plain": func() GradientOptimizer { return NewSGD(0.1, 0) },
+	}
+
+	for name, newOptimizer := range optimizers {
+		spec := Spec{
+			Sizes:       []int{2, 16, 1},
+			Activations: []Activation{{Function: Tanh}, {Function: Identity}},
+			WeightInit:  Xavier,
+			BiasInit:    Zeros,
+		}
+		backprop := NewNetwork(spec).NewBackprop()
+		optimizer := newOptimizer()
+
+		start := backprop.Train(inputs, targets, MeanSquaredError, optimizer)
+		loss := start
+		for step := 0; step < 2000; step++ {
+			loss = backprop.Train(inputs, targets, MeanSquaredError, optimizer)
+		}
+
+		if loss > 0.01 || loss > start/10 {
+			t.Errorf("%s: loss went from %v to %v", name, start, loss)
+		}
+	}
+}
+
+func TestGradientStepRefreshesFloat32(t *testing.T) {
+	network := NewNetwork(DefaultSpec(2, 3, 1))
+	input := []float64{0.5, -0.5}
+	network.feedForward32(input)
+
+	gradient := make([]float64, network.ParameterCount())
+	for i := range gradient {
+		gradient[i] = 1
+	}
+	NewAdam(0.1).Step(network, gradient)
+
+	expected := network.Forward(input)[0]
+	if got := network.feedForward32(input)[0]; math.Abs(got-expected) > 1e-5 {
+		t.Errorf("float32 run gave %v after a step, float64 gave %v", got, expected)
+	}
+}
@@ -0,0 +1,139 @@
+package network
+
+type BatchFeedForward func(inputs [][]float64) [][]float64
+
+// inference runs a network without allocating by reusing its buffers between calls, the network's weights
+// are only read so several inferences can share one network, but each inference belongs to one goroutine
+type inference struct {
+	network *Network
+
+	// output of each layer for a single input
+	activations [][]float64
+
+	// outputs of each layer for a batch, one contiguous block per layer with a row view per input
+	batchSize        int
+	batchBlocks      [][]float64
+	batchActivations [][][]float64
+}
+
+func newInference(network *Network) *inference {
+	activations := make([][]float64, len(network.biases))
+	for layer := range activations {
+		activations[layer] = make([]float64, len(network.biases[layer]))
+	}
+
+	return &inference{network: network, activations: activations}
+}
+
+// newFeedForward gives out a FeedForward with its own buffers, safe to use alongside the network's own feedForward
+func (n *Network) newFeedForward() FeedForward {
+	return newInference(n).feedForward
+}
+
+func (n *Network) newBatchFeedForward() BatchFeedForward {
+	return newInference(n).feedForwardBatch
+}
+
+func (inf *inference) checkInput(input []float64) {
+	// confirm that the input is the correct size
+	if len(input) != (len(inf.network.weights[0]) / len(inf.network.biases[0])) {
+		panic("Input size does not match network input size")
+	}
+}
+
+func (inf *inference) feedForward(input []float64) []float64 {
+	inf.checkInput(input)
+
+	// loop through each synapse (between the layers)
+	for synapseIndex, weights := range inf.network.weights {
+		output := inf.activations[synapseIndex]
+		for outputNeuronIndex := range output {
+			output[outputNeuronIndex] = 0
+		}
+
+		// loop through each neuron in the INPUT layer, its weights to the NEXT layer are next to each other
+		for inputNeuronIndex, value := range input {
+			row := weights[inputNeuronIndex*len(output) : (inputNeuronIndex+1)*len(output)]
+			for outputNeuronIndex, weight := range row {
+				output[outputNeuronIndex] += value * weight
+			}
+		}
+
+		// add the biases
+		for outputNeuronIndex, bias := range inf.network.biases[synapseIndex] {
+			output[outputNeuronIndex] += bias
+		}
+
+		inf.network.activations[synapseIndex].apply(output)
+
+		// set input to output so that the next layer can use it
+		input = output
+	}
+
+	return input
+}
+
+// feedForwardBatch runs every input through the network at once, each weight row is loaded once for the
+// whole batch instead of once per input. The returned rows are overwritten by the next call.
+func (inf *inference) feedForwardBatch(inputs [][]float64) [][]float64 {
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	for _, input := range inputs {
+		inf.checkInput(input)
+	}
+	inf.growBatch(len(inputs))
+
+	for synapseIndex, weights := range inf.network.weights {
+		outputs := inf.batchActivations[synapseIndex][:len(inputs)]
+		biases := inf.network.biases[synapseIndex]
+		outputSize := len(biases)
+
+		for _, output := range outputs {
+			for outputNeuronIndex := range output {
+				output[outputNeuronIndex] = 0
+			}
+		}
+
+		for inputNeuronIndex := 0; inputNeuronIndex < len(inputs[0]); inputNeuronIndex++ {
+			row := weights[inputNeuronIndex*outputSize : (inputNeuronIndex+1)*outputSize]
+			for sample, output := range outputs {
+				value := inputs[sample][inputNeuronIndex]
+				for outputNeuronIndex, weight := range row {
+					output[outputNeuronIndex] += value * weight
+				}
+			}
+		}
+
+		activation := inf.network.activations[synapseIndex]
+		for _, output := range outputs {
+			for outputNeuronIndex, bias := range biases {
+				output[outputNeuronIndex] += bias
+			}
+			activation.apply(output)
+		}
+
+		inputs = outputs
+	}
+
+	return inputs
+}
+
+// growBatch makes sure the batch buffers can hold size inputs
+func (inf *inference) growBatch(size int) {
+	if size <= inf.batchSize {
+		return
+	}
+
+	inf.batchSize = size
+	inf.batchBlocks = make([][]float64, len(inf.network.biases))
+	inf.batchActivations = make([][][]float64, len(inf.network.biases))
+	for layer, biases := range inf.network.biases {
+		inf.batchBlocks[layer] = make([]float64, size*len(biases))
+		inf.batchActivations[layer] = make([][]float64, size)
+		for sample := 0; sample < size; sample++ {
+			inf.batchActivations[layer][sample] = inf.batchBlocks[layer][sample*len(biases) : (sample+1)*len(biases)]
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var benchmarkSizes = []int{44, 18, 18, 4}
+
+func benchmarkInputs(count int) [][]float64 {
+	inputs := make([][]float64, count)
+	for i := range inputs {
+		inputs[i] = make([]float64, benchmarkSizes[0])
+		for j := range inputs[i] {
+			inputs[i][j] = rand.Float64()
+		}
+	}
+
+	return inputs
+}
+
+func BenchmarkFeedForward(b *testing.B) {
+	network := NewNetwork(DefaultSpec(benchmarkSizes...))
+	input := benchmarkInputs(1)[0]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		network.feedForward(input)
+	}
+}
+
+func BenchmarkFeedForwardLoop64(b *testing.B) {
+	network := NewNetwork(DefaultSpec(benchmarkSizes...))
+	inputs := benchmarkInputs(64)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, input := range inputs {
+			network.feedForward(input)
+		}
+	}
+}
+
+func BenchmarkFeedForwardBatch64(b *testing.B) {
+	network := NewNetwork(DefaultSpec(benchmarkSizes...))
+	inputs := benchmarkInputs(64)
+	feedForwardBatch := network.newBatchFeedForward()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		feedForwardBatch(inputs)
+	}
+}
+
+func TestFeedForwardDoesNotAllocate(t *testing.T) {
+	network := NewNetwork(DefaultSpec(benchmarkSizes...))
+	input := benchmarkInputs(1)[0]
+	inputs := benchmarkInputs(16)
+	feedForwardBatch := network.newBatchFeedForward()
+	feedForwardBatch(inputs)
+
+	if allocs := testing.AllocsPerRun(100, func() { network.feedForward(input) }); allocs != 0 {
+		t.Errorf("feedForward allocated %v times per call", allocs)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { feedForwardBatch(inputs) }); allocs != 0 {
+		t.Errorf("feedForwardBatch allocated %v times per call", allocs)
+	}
+}
+
+func TestFeedForwardBatchMatchesSingle(t *testing.T) {
+	network := NewNetwork(DefaultSpec(benchmarkSizes...))
+	inputs := benchmarkInputs(10)
+	outputs := network.newBatchFeedForward()(inputs)
+
+	for i, input := range inputs {
+		expected := network.feedForward(input)
+		for j := range expected {
+			if outputs[i][j] != expected[j] {
+				t.Fatalf("input %d output %d: batch gave %v, single gave %v", i, j, outputs[i][j], expected[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package network
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Initializer decides the starting values of a new network's weights or biases
+type Initializer int
+
+const (
+	// Uniform draws from [-1, 1] whatever the size of the layer
+	Uniform Initializer = iota
+	// Xavier draws uniformly from ±sqrt(6 / (inputs + outputs)), suited to tanh and sigmoid layers
+	Xavier
+	// He draws from a normal distribution with a standard deviation of sqrt(2 / inputs), suited to ReLU layers
+	He
+	// SmallNormal draws from a normal distribution with a standard deviation of 0.1
+	SmallNormal
+	// Orthogonal makes the weight matrix of each layer orthogonal, only for weights
+	Orthogonal
+	Zeros
+)
+
+var initializerNames = map[Initializer]string{
+	Uniform:     "uniform",
+	Xavier:      "xavier",
+	He:          "he",
+	SmallNormal: "small normal",
+	Orthogonal:  "orthogonal",
+	Zeros:       "zeros",
+}
+
+func (initializer Initializer) String() string {
+	if name, ok := initializerNames[initializer]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// initializeWeights fills the weights between a layer of inputSize neurons and one of outputSize neurons
+func (initializer Initializer) initializeWeights(weights []float64, inputSize, outputSize int) {
+	switch initializer {
+	case Uniform:
+		fillUniform(weights, 1)
+	case Xavier:
+		fillUniform(weights, math.Sqrt(6/float64(inputSize+outputSize)))
+	case He:
+		fillNormal(weights, math.Sqrt(2/float64(inputSize)))
+	case SmallNormal:
+		fillNormal(weights, 0.1)
+	case Orthogonal:
+		fillOrthogonal(weights, inputSize, outputSize)
+	case Zeros:
+		fillZeros(weights)
+	}
+}
+
+func (initializer Initializer) initializeBiases(biases []float64) {
+	switch initializer {
+	case Uniform:
+		fillUniform(biases, 1)
+	case SmallNormal:
+		fillNormal(biases, 0.1)
+	case Zeros:
+		fillZeros(biases)
+	}
+}
+
+func (initializer Initializer) validate(biases bool) error {
+	if _, ok := initializerNames[initializer]; !ok {
+		return fmt.Errorf("unknown initializer %d", int(initializer))
+	}
+
+	// the others depend on the shape of the weight matrix
+	if biases && initializer != Uniform && initializer != SmallNormal && initializer != Zeros {
+		return fmt.Errorf("%s can't be used for biases", initializer)
+	}
+
+	return nil
+}
+
+func fillUniform(values []float64, limit float64) {
+	for i := range values {
+		values[i] = (rand.Float64()*2 - 1) * limit
+	}
+}
+
+func fillNormal(values []float64, deviation float64) {
+	for i := range values {
+		values[i] = rand.NormFloat64() * deviation
+	}
+}
+
+func fillZeros(values []float64) {
+	for i := range values {
+		values[i] = 0
+	}
+}
+
+// fillOrthogonal makes orthonormal whichever of the rows (one per input) or columns (one per output) there are fewer of,
+// by Gram-Schmidt on normally distributed vectors
+func fillOrthogonal(weights []float64, inputSize, outputSize int) {
+	fillNormal(weights, 1)
+
+	// vector i is element j at weights[i*stride + j*step]
+	count, length, stride, step := inputSize, outputSize, outputSize, 1
+	if inputSize > outputSize {
+		count, length, stride, step = outputSize, inputSize, 1, outputSize
+	}
+
+	at := func(vector, element int) *float64 {
+		return &weights[vector*stride+element*step]
+	}
+
+	for vector := 0; vector < count; vector++ {
+		for {
+			for previous := 0; previous < vector; previous++ {
+				dot := 0.0
+				for element := 0; element < length; element++ {
+					dot += *at(vector, element) * *at(previous, element)
+				}
+				for element := 0; element < length; element++ {
+					*at(vector, element) -= dot * *at(previous, element)
+				}
+			}
+
+			norm := 0.0
+			for element := 0; element < length; element++ {
+				norm += *at(vector, element) * *at(vector, element)
+			}
+			norm = math.Sqrt(norm)
+
+			if norm > 1e-8 {
+				for element := 0; element < length; element++ {
+					*at(vector, element) /= norm
+				}
+				break
+			}
+
+			// the random vector was (almost) a combination of the earlier ones, try another
+			for element := 0; element < length; element++ {
+				*at(vector, element) = rand.NormFloat64()
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOrthogonalWeights(t *testing.T) {
+	for _, shape := range [][2]int{{4, 4}, {3, 7}, {9, 2}} {
+		inputSize, outputSize := shape[0], shape[1]
+		weights := make([]float64, inputSize*outputSize)
+		Orthogonal.initializeWeights(weights, inputSize, outputSize)
+
+		// the shorter side's vectors should be orthonormal
+		count, length, stride, step := inputSize, outputSize, outputSize, 1
+		if inputSize > outputSize {
+			count, length, stride, step = outputSize, inputSize, 1, outputSize
+		}
+
+		for a := 0; a < count; a++ {
+			for b := 0; b < count; b++ {
+				dot := 0.0
+				for element := 0; element < length; element++ {
+					dot += weights[a*stride+element*step] * weights[b*stride+element*step]
+				}
+
+				want := 0.0
+				if a == b {
+					want = 1
+				}
+				if math.Abs(dot-want) > 1e-9 {
+					t.Errorf("%dx%d: vectors %d and %d have a dot product of %v, want %v", inputSize, outputSize, a, b, dot, want)
+				}
+			}
+		}
+	}
+}
+
+func TestSpecRejectsShapedBiasInit(t *testing.T) {
+	spec := DefaultSpec(3, 2)
+	spec.BiasInit = He
+	if spec.validate() == nil {
+		t.Error("He biases should not be allowed")
+	}
+}
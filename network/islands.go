@@ -0,0 +1,282 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MigrationTopology decides which islands send their best individuals to which
+type MigrationTopology int
+
+const (
+	// RingMigration sends migrants from each island to the next one, the last island sends to the first
+	RingMigration MigrationTopology = iota
+	// FullMigration sends migrants from every island to every other island
+	FullMigration
+	// RandomMigration sends migrants from each island to another island picked at random every time
+	RandomMigration
+)
+
+// IslandModel evolves several populations independently, every interval generations the best migrants of each island
+// replace the worst individuals of the islands it sends to. Every island is a GeneticAlgorithm with its own settings
+// and evaluation, but they all need the same spec so migrants can breed with the locals.
+type IslandModel struct {
+	islands []*GeneticAlgorithm
+
+	topology MigrationTopology
+	interval int
+	migrants int
+
+	generationNumber int
+	// whether the islands have been evaluated since they were made, for Step
+	evaluated bool
+}
+
+func NewIslandModel(islands []*GeneticAlgorithm, topology MigrationTopology, interval, migrants int) (*IslandModel, error) {
+	if len(islands) < 2 {
+		return nil, errors.New("an island model needs at least 2 islands")
+	}
+	if interval < 1 || migrants < 1 {
+		return nil, errors.New("migration needs an interval and a number of migrants of at least 1")
+	}
+
+	for i, island := range islands {
+		if !sameSizes(island.spec.Sizes, islands[0].spec.Sizes) {
+			return nil, fmt.Errorf("island %d has layers %v, island 0 has %v", i, island.spec.Sizes, islands[0].spec.Sizes)
+		}
+		if migrants*2 > island.populationSize {
+			return nil, fmt.Errorf("island %d has %d individuals, too few to take %d migrants", i, island.populationSize, migrants)
+		}
+	}
+
+	return &IslandModel{islands: islands, topology: topology, interval: interval, migrants: migrants}, nil
+}
+
+func sameSizes(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Islands are the algorithms of every island, to change their settings or read their results
+func (model *IslandModel) Islands() []*GeneticAlgorithm {
+	return model.islands
+}
+
+// EvaluateGeneration evaluates every island at the same time, each on its own goroutine, so the evaluation functions
+// must be safe to call concurrently
+func (model *IslandModel) EvaluateGeneration() {
+	var wait sync.WaitGroup
+	for _, island := range model.islands {
+		wait.Add(1)
+		go func(island *GeneticAlgorithm) {
+			defer wait.Done()
+			island.EvaluateGeneration()
+		}(island)
+	}
+	wait.Wait()
+}
+
+// bestIsland is the island with the best individual of the last evaluated generation
+func (model *IslandModel) bestIsland() *GeneticAlgorithm {
+	best := model.islands[0]
+
+	for _, island := range model.islands {
+		if island.performance(island.bestIndividual()) > best.performance(best.bestIndividual()) {
+			best = island
+		}
+	}
+
+	return best
+}
+
+// GetBestIndividual prints the stats of the last evaluated generation and returns its best network
+func (model *IslandModel) GetBestIndividual() FeedForward {
+	fmt.Print(model.Stats())
+
+	return model.Best()
+}
+
+func (model *IslandModel) Best() FeedForward {
+	return model.bestIsland().Best()
+}
+
+// Stats has the best fitness of every island as a detail
+func (model *IslandModel) Stats() Stats {
+	best := model.bestIsland()
+	stats := Stats{Generation: model.generationNumber, BestFitness: best.performance(best.bestIndividual())}
+
+	fitness := make([]string, len(model.islands))
+	for i, island := range model.islands {
+		fitness[i] = fmt.Sprintf("%f", island.performance(island.bestIndividual()))
+	}
+	stats.Details = append(stats.Details, Detail{Name: "Islands", Value: strings.Join(fitness, " ")})
+
+	return stats
+}
+
+// Step evolves and migrates, unless no generation has been evaluated yet, and evaluates every island
+func (model *IslandModel) Step() {
+	if model.evaluated {
+		model.EvolveGeneration()
+	}
+	model.EvaluateGeneration()
+	model.evaluated = true
+}
+
+// islandCheckpoint is the state of an IslandModel in a checkpoint
+type islandCheckpoint struct {
+	Generation int                 `json:"generation"`
+	Evaluated  bool                `json:"evaluated"`
+	Islands    []geneticCheckpoint `json:"islands"`
+}
+
+// Checkpoint writes every island to w as JSON
+func (model *IslandModel) Checkpoint(w io.Writer) error {
+	checkpoint := islandCheckpoint{Generation: model.generationNumber, Evaluated: model.evaluated}
+	for _, island := range model.islands {
+		checkpoint.Islands = append(checkpoint.Islands, island.checkpoint())
+	}
+
+	return writeCheckpoint(w, checkpoint)
+}
+
+// Restore continues from a checkpoint written by Checkpoint of a model with the same islands
+func (model *IslandModel) Restore(r io.Reader) error {
+	var checkpoint islandCheckpoint
+	if err := readCheckpoint(r, &checkpoint); err != nil {
+		return err
+	}
+	if len(checkpoint.Islands) != len(model.islands) {
+		return fmt.Errorf("checkpoint has %d islands, the model has %d", len(checkpoint.Islands), len(model.islands))
+	}
+
+	for i, island := range model.islands {
+		if err := island.restore(checkpoint.Islands[i]); err != nil {
+			return fmt.Errorf("island %d: %w", i, err)
+		}
+	}
+	model.generationNumber = checkpoint.Generation
+	model.evaluated = checkpoint.Evaluated
+
+	return nil
+}
+
+// SaveBest writes the best individual of all the islands to path as JSON
+func (model *IslandModel) SaveBest(path string) error {
+	return model.bestIsland().SaveBest(path)
+}
+
+func (model *IslandModel) EvolveGeneration() {
+	if (model.generationNumber+1)%model.interval == 0 {
+		model.migrate()
+	}
+
+	for _, island := range model.islands {
+		island.EvolveGeneration()
+	}
+	model.generationNumber++
+}
+
+// destinations are the islands the island at index sends its migrants to
+func (model *IslandModel) destinations(index int) []int {
+	switch model.topology {
+	case FullMigration:
+		destinations := make([]int, 0, len(model.islands)-1)
+		for i := range model.islands {
+			if i != index {
+				destinations = append(destinations, i)
+			}
+		}
+		return destinations
+	case RandomMigration:
+		destination := rand.Intn(len(model.islands) - 1)
+		if destination >= index {
+			destination++
+		}
+		return []int{destination}
+	default:
+		return []int{(index + 1) % len(model.islands)}
+	}
+}
+
+// migrate copies the best individuals of every island over the worst ones of the islands it sends to,
+// the migrants are picked before any island receives some so they only travel one step
+func (model *IslandModel) migrate() {
+	incoming := make([][]*individual, len(model.islands))
+	for i, island := range model.islands {
+		ranked := island.rankedPopulation()
+		for _, destination := range model.destinations(i) {
+			for _, migrant := range ranked[:model.migrants] {
+				copied := newEmptyIndividual(model.islands[destination].spec)
+				copy(copied.parameters, migrant.parameters)
+				copied.fitness = migrant.fitness
+				copied.objective = migrant.objective
+				copied.behavior = migrant.behavior
+				incoming[destination] = append(incoming[destination], copied)
+			}
+		}
+	}
+
+	for i, island := range model.islands {
+		island.receiveMigrants(incoming[i])
+	}
+}
+
+// rankedPopulation is a copy of the population sorted best first
+func (ga *GeneticAlgorithm) rankedPopulation() []*individual {
+	ranked := append([]*individual(nil), ga.population...)
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].fitness > ranked[j].fitness
+	})
+
+	return ranked
+}
+
+// receiveMigrants replaces the worst individuals with the migrants, which are scored again by this island's own
+// evaluation since it may value different things than the island they came from
+func (ga *GeneticAlgorithm) receiveMigrants(migrants []*individual) {
+	if len(migrants) == 0 {
+		return
+	}
+	if len(migrants) > ga.populationSize/2 {
+		migrants = migrants[:ga.populationSize/2]
+	}
+
+	// group evaluated fitness depends on the opponents and novelty on the rest of the population,
+	// so those migrants keep the scores they earned at home
+	if ga.group == nil && ga.novelty == nil {
+		fitnessTrack := make([]float64, 5)
+		for _, migrant := range migrants {
+			ga.evaluateIndividual(migrant, fitnessTrack)
+		}
+	}
+
+	ranked := ga.rankedPopulation()
+	replaced := make(map[*individual]*individual, len(migrants))
+	for i, migrant := range migrants {
+		replaced[ranked[len(ranked)-1-i]] = migrant
+	}
+	for i, individual := range ga.population {
+		if migrant, ok := replaced[individual]; ok {
+			ga.population[i] = migrant
+		}
+	}
+
+	// the migrants join species, the threshold was already adjusted for this generation when it was evaluated
+	if ga.niching != nil {
+		ga.assignSpecies()
+	}
+}
@@ -0,0 +1,84 @@
+package network
+
+import "testing"
+
+func TestIslandMigration(t *testing.T) {
+	islands := make([]*GeneticAlgorithm, 3)
+	for i := range islands {
+		// each island rewards a different output, so migrants have to be scored again
+		output := i % 2
+		islands[i] = NewGeneticAlgoritm(10, DefaultSpec(2, 2), 0.1, 0.1, func(feedForward FeedForward) float64 {
+			return feedForward([]float64{1, -1})[output]
+		})
+	}
+
+	model, err := NewIslandModel(islands, RingMigration, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model.EvaluateGeneration()
+	best := islands[0].rankedPopulation()[0].Clone()
+	model.migrate()
+
+	found := false
+	for _, individual := range islands[1].population {
+		if genomeDistance(individual.Network, best) == 0 {
+			found = true
+		}
+		if want := individual.feedForward([]float64{1, -1})[1]; individual.fitness != want {
+			t.Errorf("individual on island 1 has fitness %v, its own evaluation gives %v", individual.fitness, want)
+		}
+	}
+	if !found {
+		t.Error("island 0's best individual didn't reach island 1")
+	}
+
+	model.EvolveGeneration()
+	for i, island := range islands {
+		if len(island.population) != 10 {
+			t.Errorf("island %d has %d individuals", i, len(island.population))
+		}
+	}
+}
+
+func TestIslandModelRejectsDifferentSpecs(t *testing.T) {
+	islands := []*GeneticAlgorithm{
+		NewGeneticAlgoritm(10, DefaultSpec(2, 2), 0, 0, nil),
+		NewGeneticAlgoritm(10, DefaultSpec(2, 3), 0, 0, nil),
+	}
+	if _, err := NewIslandModel(islands, FullMigration, 1, 1); err == nil {
+		t.Error("islands with different layers were accepted")
+	}
+}
+
+func TestMigrationKeepsNichingThreshold(t *testing.T) {
+	islands := make([]*GeneticAlgorithm, 2)
+	for i := range islands {
+		islands[i] = NewGeneticAlgoritm(10, DefaultSpec(2, 2), 0.1, 0.1, func(feedForward FeedForward) float64 {
+			return feedForward([]float64{1, -1})[0]
+		})
+		islands[i].UseNiching(Niching{Threshold: 0.5, TargetSpecies: 100})
+	}
+
+	model, err := NewIslandModel(islands, RingMigration, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// evaluating tunes the threshold once, migrants joining species afterwards mustn't tune it again
+	model.EvaluateGeneration()
+	threshold := islands[1].niching.Threshold
+	model.migrate()
+
+	if islands[1].niching.Threshold != threshold {
+		t.Errorf("threshold went from %v to %v when migrants arrived", threshold, islands[1].niching.Threshold)
+	}
+	total := 0
+	for _, size := range islands[1].SpeciesSizes() {
+		total += size
+	}
+	if total != 10 {
+		t.Errorf("species hold %d individuals after migration", total)
+	}
+}
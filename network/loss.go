@@ -0,0 +1,69 @@
+package network
+
+import "math"
+
+// Loss measures how far a network's outputs are from the outputs it should have given
+type Loss int
+
+const (
+	// MeanSquaredError is the mean of the squared differences between the outputs and the targets
+	MeanSquaredError Loss = iota
+	// CrossEntropy is -sum(target * ln(output)), for outputs that are probabilities like those of a softmax layer
+	CrossEntropy
+	// Huber is the mean of half the squared differences up to 1 and of the differences minus a half beyond, so a few
+	// outliers can't swamp the gradient
+	Huber
+)
+
+func (loss Loss) String() string {
+	switch loss {
+	case MeanSquaredError:
+		return "mse"
+	case CrossEntropy:
+		return "cross-entropy"
+	case Huber:
+		return "huber"
+	}
+
+	return "unknown"
+}
+
+// Evaluate returns the loss of the outputs and writes its gradient with respect to each output into gradient
+func (loss Loss) Evaluate(outputs, targets, gradient []float64) float64 {
+	if len(outputs) != len(targets) || len(outputs) != len(gradient) {
+		panic("Outputs, targets and gradient must be the same size")
+	}
+
+	total := 0.0
+	count := float64(len(outputs))
+	switch loss {
+	case MeanSquaredError:
+		for i, output := range outputs {
+			difference := output - targets[i]
+			total += difference * difference / count
+			gradient[i] = 2 * difference / count
+		}
+	case CrossEntropy:
+		for i, output := range outputs {
+			// an output of exactly 0 would make the loss infinite
+			output = math.Max(output, 1e-12)
+			total -= targets[i] * math.Log(output)
+			gradient[i] = -targets[i] / output
+		}
+	case Huber:
+		for i, output := range outputs {
+			difference := output - targets[i]
+			if math.Abs(difference) <= 1 {
+				total += 0.5 * difference * difference / count
+				gradient[i] = difference / count
+			} else {
+				total += (math.Abs(difference) - 0.5) / count
+				gradient[i] = math.Copysign(1, difference) / count
+			}
+		}
+	default:
+		panic("unknown loss")
+	}
+
+	return total
+}
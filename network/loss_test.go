@@ -0,0 +1,91 @@
+package network
+
+import (
+	"math"
+	"testing"
+)
+
+var losses = []Loss{MeanSquaredError, CrossEntropy, Huber}
+
+func TestLossGradientsMatchFiniteDifferences(t *testing.T) {
+	// the differences of the Huber loss fall on both sides of 1
+	outputs := []float64{0.2, 0.5, 0.3, 2.5}
+	targets := []float64{0, 1, 0, 0.1}
+
+	for _, loss := range losses {
+		gradient := make([]float64, len(outputs))
+		loss.Evaluate(outputs, targets, gradient)
+
+		scratch := make([]float64, len(outputs))
+		for i := range outputs {
+			value := outputs[i]
+			outputs[i] = value + 1e-6
+			above := loss.Evaluate(outputs, targets, scratch)
+			outputs[i] = value - 1e-6
+			below := loss.Evaluate(outputs, targets, scratch)
+			outputs[i] = value
+
+			if expected := (above - below) / 2e-6; math.Abs(gradient[i]-expected) > 1e-5 {
+				t.Errorf("%s: output %d has gradient %v, expected %v", loss, i, gradient[i], expected)
+			}
+		}
+	}
+}
+
+func TestLossIsZeroOnTarget(t *testing.T) {
+	targets := []float64{0.25, 0.75}
+	gradient := make([]float64, 2)
+
+	for _, loss := range []Loss{MeanSquaredError, Huber} {
+		if value := loss.Evaluate(targets, targets, gradient); value != 0 || gradient[0] != 0 || gradient[1] != 0 {
+			t.Errorf("%s: loss %v with gradient %v on the targets", loss, value, gradient)
+		}
+	}
+
+	// cross-entropy bottoms out at the entropy of the targets
+	entropy := -0.25*math.Log(0.25) - 0.75*math.Log(0.75)
+	if value := CrossEntropy.Evaluate(targets, targets, gradient); math.Abs(value-entropy) > 1e-12 {
+		t.Errorf("cross-entropy of the targets with themselves is %v, expected %v", value, entropy)
+	}
+}
+
+// the gradient Train steps along is the gradient of the average loss over the batch
+func TestBatchGradientMatchesFiniteDifferences(t *testing.T) {
+	inputs := [][]float64{{0.3, -0.7}, {1.2, 0.4}, {-0.5, 0.9}}
+	targets := [][]float64{{0.1, 0.9}, {0.6, 0.4}, {0.5, 0.5}}
+
+	for _, loss := range losses {
+		spec := DefaultSpec(2, 5, 2)
+		spec.Activations[1] = Activation{Function: Softmax}
+		network := NewNetwork(spec)
+		averageLoss := func() float64 {
+			total := 0.0
+			gradient := make([]float64, 2)
+			for i, input := range inputs {
+				total += loss.Evaluate(network.Forward(input), targets[i], gradient)
+			}
+			return total / float64(len(inputs))
+		}
+
+		// plain SGD with a learning rate of 1 moves the parameters by exactly the negative gradient
+		before := network.Genome()
+		network.NewBackprop().Train(inputs, targets, loss, NewSGD(1, 0))
+		after := network.Genome()
+		if err := network.SetGenome(before); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range network.parameters {
+			network.parameters[i] = before[i] + 1e-6
+			above := averageLoss()
+			network.parameters[i] = before[i] - 1e-6
+			below := averageLoss()
+			network.parameters[i] = before[i]
+
+			expected := (above - below) / 2e-6
+			if gradient := before[i] - after[i]; math.Abs(gradient-expected) > 1e-5 {
+				t.Errorf("%s: parameter %d has gradient %v, expected %v", loss, i, gradient, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// BehaviorDimension is one axis of the MAP-Elites grid, entry Index of the behavior split into Bins between Min and Max,
+// values outside the range go into the first or last bin
+type BehaviorDimension struct {
+	Index    int
+	Min, Max float64
+	Bins     int
+}
+
+func (dimension BehaviorDimension) bin(behavior []float64) int {
+	bin := int((behavior[dimension.Index] - dimension.Min) / (dimension.Max - dimension.Min) * float64(dimension.Bins))
+	if bin < 0 {
+		return 0
+	}
+	if bin >= dimension.Bins {
+		return dimension.Bins - 1
+	}
+
+	return bin
+}
+
+// MAPElites keeps the best network found for every cell of a grid of behaviors instead of a population, each
+// generation is a batch of children of random elites that take over the cells they land in if they do better
+type MAPElites struct {
+	batchSize  int
+	spec       Spec
+	dimensions []BehaviorDimension
+	// elite of each cell, nil for cells nothing has landed in yet, the first dimension changes fastest
+	cells []*individual
+	// children waiting to be evaluated
+	batch []*individual
+
+	generationNumber int
+	// whether the batch has been evaluated since it was made, for Step
+	evaluated bool
+
+	mutationChance float64
+	mutationRate   float64
+
+	evaluate evaluateBehavior
+}
+
+func NewMAPElites(batchSize int, spec Spec, dimensions []BehaviorDimension, mutationChance, mutationRate float64, evaluate evaluateBehavior) *MAPElites {
+	if err := spec.validate(); err != nil {
+		panic(err)
+	}
+	if len(dimensions) == 0 {
+		panic("MAP-Elites needs at least one behavior dimension")
+	}
+
+	cellCount := 1
+	for _, dimension := range dimensions {
+		if dimension.Bins < 1 || dimension.Max <= dimension.Min {
+			panic(fmt.Sprintf("behavior dimension %d needs at least 1 bin and a max above its min", dimension.Index))
+		}
+		cellCount *= dimension.Bins
+	}
+
+	mapElites := &MAPElites{
+		batchSize:      batchSize,
+		spec:           spec,
+		dimensions:     dimensions,
+		cells:          make([]*individual, cellCount),
+		mutationChance: mutationChance,
+		mutationRate:   mutationRate,
+		evaluate:       evaluate,
+	}
+
+	// the first batch is random, there are no elites to breed from yet
+	for i := 0; i < batchSize; i++ {
+		mapElites.batch = append(mapElites.batch, newIndividual(spec))
+	}
+
+	return mapElites
+}
+
+func (m *MAPElites) cell(behavior []float64) int {
+	cell, stride := 0, 1
+	for _, dimension := range m.dimensions {
+		cell += dimension.bin(behavior) * stride
+		stride *= dimension.Bins
+	}
+
+	return cell
+}
+
+// EvaluateGeneration evaluates the batch and puts every child that beats the elite of its cell in its place
+func (m *MAPElites) EvaluateGeneration() {
+	fitnessTrack := make([]float64, 5)
+	for _, child := range m.batch {
+		evaluateBehaviorMedian(m.evaluate, child.feedForward, child, fitnessTrack)
+		child.fitness = child.objective
+
+		cell := m.cell(child.behavior)
+		if m.cells[cell] == nil || child.fitness > m.cells[cell].fitness {
+			m.cells[cell] = child
+		}
+	}
+}
+
+// elites are the occupied cells
+func (m *MAPElites) elites() []*individual {
+	elites := []*individual{}
+	for _, elite := range m.cells {
+		if elite != nil {
+			elites = append(elites, elite)
+		}
+	}
+
+	return elites
+}
+
+func (m *MAPElites) bestIndividual() *individual {
+	var best *individual
+	for _, elite := range m.cells {
+		if elite != nil && (best == nil || elite.fitness > best.fitness) {
+			best = elite
+		}
+	}
+
+	return best
+}
+
+// GetBestIndividual prints the stats of the last evaluated generation and returns the best elite
+func (m *MAPElites) GetBestIndividual() FeedForward {
+	fmt.Print(m.Stats())
+
+	return m.Best()
+}
+
+// Best runs the best elite of the grid, it is nil until a batch has been evaluated and the grid has an elite
+func (m *MAPElites) Best() FeedForward {
+	best := m.bestIndividual()
+	if best == nil {
+		return nil
+	}
+
+	return best.newFeedForward()
+}
+
+// Stats has how much of the grid is filled as a detail, the best fitness is the best elite's or 0 on an empty grid
+func (m *MAPElites) Stats() Stats {
+	stats := Stats{
+		Generation: m.generationNumber,
+		Details:    []Detail{detail("Cells", "%d/%d", len(m.elites()), len(m.cells))},
+	}
+	if best := m.bestIndividual(); best != nil {
+		stats.BestFitness = best.fitness
+	}
+
+	return stats
+}
+
+// Step makes a new batch from the elites, unless none has been evaluated yet, and evaluates it
+func (m *MAPElites) Step() {
+	if m.evaluated {
+		m.EvolveGeneration()
+	}
+	m.EvaluateGeneration()
+	m.evaluated = true
+}
+
+// mapElitesCheckpoint is the state of a MAPElites run in a checkpoint
+type mapElitesCheckpoint struct {
+	Generation int               `json:"generation"`
+	Evaluated  bool              `json:"evaluated"`
+	Cells      []savedIndividual `json:"cells"`
+	Batch      []savedIndividual `json:"batch"`
+}
+
+// Checkpoint writes the grid of elites and the current batch to w as JSON
+func (m *MAPElites) Checkpoint(w io.Writer) error {
+	return writeCheckpoint(w, mapElitesCheckpoint{
+		Generation: m.generationNumber,
+		Evaluated:  m.evaluated,
+		Cells:      saveIndividuals(m.cells),
+		Batch:      saveIndividuals(m.batch),
+	})
+}
+
+// Restore continues from a checkpoint written by Checkpoint of a run with the same grid
+func (m *MAPElites) Restore(r io.Reader) error {
+	var checkpoint mapElitesCheckpoint
+	if err := readCheckpoint(r, &checkpoint); err != nil {
+		return err
+	}
+	if len(checkpoint.Cells) != len(m.cells) {
+		return fmt.Errorf("checkpoint has %d cells, the grid has %d", len(checkpoint.Cells), len(m.cells))
+	}
+
+	cells, err := loadIndividuals(m.spec, checkpoint.Cells)
+	if err != nil {
+		return err
+	}
+	batch, err := loadIndividuals(m.spec, checkpoint.Batch)
+	if err != nil {
+		return err
+	}
+	for _, child := range batch {
+		if child == nil {
+			return errors.New("checkpoint has an empty entry in its batch")
+		}
+	}
+
+	m.cells = cells
+	m.batch = batch
+	m.generationNumber = checkpoint.Generation
+	m.evaluated = checkpoint.Evaluated
+
+	return nil
+}
+
+// SaveBest writes the best elite to path as JSON
+func (m *MAPElites) SaveBest(path string) error {
+	best := m.bestIndividual()
+	if best == nil {
+		return errors.New("MAP-Elites has no elite to save yet")
+	}
+
+	return best.Save(path)
+}
+
+// EvolveGeneration makes the next batch by crossing over and mutating random elites
+func (m *MAPElites) EvolveGeneration() {
+	elites := m.elites()
+	if len(elites) == 0 {
+		panic("MAP-Elites has to evaluate a batch before evolving it")
+	}
+
+	m.batch = m.batch[:0]
+	for i := 0; i < m.batchSize; i++ {
+		child := crossoverParents(m.spec, elites[rand.Intn(len(elites))], elites[rand.Intn(len(elites))])
+		mutateIndividual(child, m.mutationChance, m.mutationRate)
+		m.batch = append(m.batch, child)
+	}
+	m.generationNumber++
+}
+
+// Heatmap draws the grid with one pixel per cell of the first two dimensions, from dark blue for the lowest elite
+// fitness to yellow for the highest, empty cells are black. With more than two dimensions each pixel shows the best
+// elite across the others, with one dimension the image is a single row.
+func (m *MAPElites) Heatmap() *image.RGBA {
+	width, height := m.dimensions[0].Bins, 1
+	if len(m.dimensions) > 1 {
+		height = m.dimensions[1].Bins
+	}
+
+	best := make([]*individual, width*height)
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, elite := range m.cells {
+		if elite == nil {
+			continue
+		}
+
+		pixel := m.dimensions[0].bin(elite.behavior)
+		if len(m.dimensions) > 1 {
+			pixel += m.dimensions[1].bin(elite.behavior) * width
+		}
+		if best[pixel] == nil || elite.fitness > best[pixel].fitness {
+			best[pixel] = elite
+		}
+
+		lowest = math.Min(lowest, elite.fitness)
+		highest = math.Max(highest, elite.fitness)
+	}
+
+	heatmap := image.NewRGBA(image.Rect(0, 0, width, height))
+	for pixel, elite := range best {
+		// higher bins of the second dimension go at the top
+		x, y := pixel%width, height-1-pixel/width
+		if elite == nil {
+			heatmap.Set(x, y, color.RGBA{0, 0, 0, 255})
+			continue
+		}
+
+		scale := 1.0
+		if highest > lowest {
+			scale = (elite.fitness - lowest) / (highest - lowest)
+		}
+		heatmap.Set(x, y, color.RGBA{uint8(255 * scale), uint8(40 + 215*scale), uint8(120 * (1 - scale)), 255})
+	}
+
+	return heatmap
+}
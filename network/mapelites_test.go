@@ -0,0 +1,59 @@
+package network
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+)
+
+func TestMAPElitesKeepsBestPerCell(t *testing.T) {
+	dimensions := []BehaviorDimension{{Index: 0, Min: 0, Max: 1, Bins: 4}, {Index: 1, Min: 0, Max: 1, Bins: 2}}
+	m := NewMAPElites(50, DefaultSpec(2, 2), dimensions, 0.2, 0.5, func(feedForward FeedForward) (float64, []float64) {
+		output := feedForward([]float64{1, -1})
+		return output[0] + output[1], []float64{output[0], output[1]}
+	})
+
+	for generation := 0; generation < 3; generation++ {
+		m.EvaluateGeneration()
+		m.EvolveGeneration()
+	}
+	m.EvaluateGeneration()
+
+	for cell, elite := range m.cells {
+		if elite != nil && m.cell(elite.behavior) != cell {
+			t.Errorf("elite with behavior %v is in cell %d", elite.behavior, cell)
+		}
+	}
+
+	heatmap := m.Heatmap()
+	if bounds := heatmap.Bounds(); bounds.Dx() != 4 || bounds.Dy() != 2 {
+		t.Fatalf("heatmap is %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	best := m.bestIndividual()
+	x, y := dimensions[0].bin(best.behavior), 1-dimensions[1].bin(best.behavior)
+	if heatmap.At(x, y) != (color.RGBA{255, 255, 0, 255}) {
+		t.Errorf("best elite's pixel is %v", heatmap.At(x, y))
+	}
+}
+
+func TestMAPElitesBeforeEvaluating(t *testing.T) {
+	dimensions := []BehaviorDimension{{Index: 0, Min: 0, Max: 1, Bins: 4}}
+	m := NewMAPElites(10, DefaultSpec(2, 2), dimensions, 0.2, 0.5, func(feedForward FeedForward) (float64, []float64) {
+		return 1, []float64{0.5}
+	})
+
+	if m.Best() != nil {
+		t.Error("empty grid has a best network")
+	}
+	if stats := m.Stats(); stats.BestFitness != 0 || stats.Generation != 0 {
+		t.Errorf("empty grid has stats %+v", stats)
+	}
+	if err := m.SaveBest(filepath.Join(t.TempDir(), "network.json")); err == nil {
+		t.Error("saved the best of an empty grid")
+	}
+
+	m.Step()
+	if m.Best() == nil || m.Stats().BestFitness != 1 {
+		t.Error("no best network after the first step")
+	}
+}
@@ -0,0 +1,490 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// NEATConfig holds the settings of a NEAT run, start from DefaultNEATConfig and change what's needed
+type NEATConfig struct {
+	Inputs  int
+	Outputs int
+
+	// activation of new hidden nodes and of the output nodes, they work on single neurons so Softmax isn't allowed
+	HiddenActivation Activation
+	OutputActivation Activation
+
+	// weights of the compatibility distance
+	ExcessCoefficient   float64
+	DisjointCoefficient float64
+	WeightCoefficient   float64
+	// genomes closer than this to a species' representative join that species
+	CompatibilityThreshold float64
+
+	// chance of each weight and bias being nudged by up to WeightMutationRate, or being replaced by a random value instead
+	WeightMutationChance float64
+	WeightMutationRate   float64
+	WeightReplaceChance  float64
+	// chance of a child getting a new connection or splitting a connection with a new node
+	AddConnectionChance float64
+	AddNodeChance       float64
+	// chance of a child being made from two parents instead of being a mutated copy of one
+	CrossoverChance float64
+	// chance that a connection disabled in either parent stays disabled in the child
+	KeepDisabledChance float64
+
+	// share of each species, best first, that gets to have children
+	SurvivalThreshold float64
+	// species that haven't improved in this many generations are dropped, unless they are among the best two
+	StagnationLimit int
+}
+
+// DefaultNEATConfig uses the settings from the original NEAT paper
+func DefaultNEATConfig(inputs, outputs int) NEATConfig {
+	return NEATConfig{
+		Inputs:                 inputs,
+		Outputs:                outputs,
+		HiddenActivation:       Activation{Function: Sigmoid},
+		OutputActivation:       Activation{Function: Sigmoid},
+		ExcessCoefficient:      1,
+		DisjointCoefficient:    1,
+		WeightCoefficient:      0.4,
+		CompatibilityThreshold: 3,
+		WeightMutationChance:   0.8,
+		WeightMutationRate:     0.5,
+		WeightReplaceChance:    0.1,
+		AddConnectionChance:    0.05,
+		AddNodeChance:          0.03,
+		CrossoverChance:        0.75,
+		KeepDisabledChance:     0.75,
+		SurvivalThreshold:      0.2,
+		StagnationLimit:        15,
+	}
+}
+
+func (config NEATConfig) validate() error {
+	if config.Inputs < 1 || config.Outputs < 1 {
+		return errors.New("NEAT networks need at least one input and one output")
+	}
+
+	for _, activation := range []Activation{config.HiddenActivation, config.OutputActivation} {
+		if _, ok := activationNames[activation.Function]; !ok {
+			return fmt.Errorf("unknown activation function %d", int(activation.Function))
+		}
+		if activation.Function == Softmax {
+			return errors.New("softmax needs a whole layer and can't be used on NEAT nodes")
+		}
+	}
+
+	if config.CompatibilityThreshold <= 0 {
+		return errors.New("compatibility threshold must be above 0")
+	}
+
+	return nil
+}
+
+type species struct {
+	// genome new members are compared against, a member of the previous generation
+	representative *neatGenome
+	members        []*neatGenome
+
+	bestFitness float64
+	// generations since bestFitness last went up
+	stagnation int
+}
+
+// NEAT evolves the topology of networks along with their weights, starting from inputs connected straight to
+// the outputs and adding nodes and connections as they prove useful
+type NEAT struct {
+	populationSize int
+	config         NEATConfig
+	population     []*neatGenome
+	species        []*species
+
+	generationNumber int
+	// whether the population has been evaluated since it was made, for Step
+	evaluated bool
+
+	evaluate evaluateIndividual
+
+	nextNodeID     int
+	nextInnovation int
+	// innovation numbers of every connection seen so far, so the same link always gets the same number
+	innovations map[[2]int]int
+	// node added by splitting each connection, so the same split always gets the same node
+	splits map[int]int
+}
+
+func NewNEAT(populationSize int, config NEATConfig, evaluate evaluateIndividual) *NEAT {
+	if err := config.validate(); err != nil {
+		panic(err)
+	}
+
+	neat := &NEAT{
+		populationSize: populationSize,
+		config:         config,
+		evaluate:       evaluate,
+		innovations:    map[[2]int]int{},
+		splits:         map[int]int{},
+	}
+
+	neat.generatePopulation()
+
+	return neat
+}
+
+// generatePopulation connects every input to every output with random weights
+func (neat *NEAT) generatePopulation() {
+	neat.population = make([]*neatGenome, neat.populationSize)
+	for i := range neat.population {
+		genome := &neatGenome{}
+		for input := 0; input < neat.config.Inputs; input++ {
+			genome.nodes = append(genome.nodes, nodeGene{ID: input, Kind: inputNode})
+		}
+		for output := 0; output < neat.config.Outputs; output++ {
+			id := neat.config.Inputs + output
+			genome.nodes = append(genome.nodes, nodeGene{ID: id, Kind: outputNode, Bias: randomWeight(), Activation: neat.config.OutputActivation})
+
+			for input := 0; input < neat.config.Inputs; input++ {
+				genome.addConnection(connectionGene{In: input, Out: id, Weight: randomWeight(), Enabled: true, Innovation: neat.innovation(input, id)})
+			}
+		}
+
+		neat.population[i] = genome
+	}
+
+	neat.nextNodeID = neat.config.Inputs + neat.config.Outputs
+}
+
+// innovation is the innovation number of a connection between two nodes, new links get the next number
+func (neat *NEAT) innovation(in, out int) int {
+	key := [2]int{in, out}
+	if innovation, ok := neat.innovations[key]; ok {
+		return innovation
+	}
+
+	innovation := neat.nextInnovation
+	neat.nextInnovation++
+	neat.innovations[key] = innovation
+
+	return innovation
+}
+
+func (neat *NEAT) EvaluateGeneration() {
+	fitnessTrack := make([]float64, 5)
+
+	for _, genome := range neat.population {
+		genome.fitness = medianFitness(neat.evaluate, newNEATNetwork(genome).feedForward, fitnessTrack)
+	}
+
+	neat.speciate()
+}
+
+// speciate splits the population into species by compatibility distance and shares fitness within them
+func (neat *NEAT) speciate() {
+	for _, existing := range neat.species {
+		existing.members = existing.members[:0]
+	}
+
+	for _, genome := range neat.population {
+		var found *species
+		for _, existing := range neat.species {
+			if genome.compatibility(existing.representative, &neat.config) < neat.config.CompatibilityThreshold {
+				found = existing
+				break
+			}
+		}
+
+		if found == nil {
+			found = &species{representative: genome, bestFitness: math.Inf(-1)}
+			neat.species = append(neat.species, found)
+		}
+		found.members = append(found.members, genome)
+	}
+
+	// fitness can be negative, shift it so the worst genome is at 0 before sharing it out
+	lowest := math.Inf(1)
+	for _, genome := range neat.population {
+		lowest = math.Min(lowest, genome.fitness)
+	}
+
+	alive := neat.species[:0]
+	for _, existing := range neat.species {
+		if len(existing.members) == 0 {
+			continue
+		}
+
+		sort.Slice(existing.members, func(i, j int) bool {
+			return existing.members[i].fitness > existing.members[j].fitness
+		})
+
+		if best := existing.members[0].fitness; best > existing.bestFitness {
+			existing.bestFitness = best
+			existing.stagnation = 0
+		} else {
+			existing.stagnation++
+		}
+
+		for _, genome := range existing.members {
+			genome.adjustedFitness = (genome.fitness - lowest) / float64(len(existing.members))
+		}
+
+		alive = append(alive, existing)
+	}
+	neat.species = alive
+
+	sort.Slice(neat.species, func(i, j int) bool {
+		return neat.species[i].members[0].fitness > neat.species[j].members[0].fitness
+	})
+}
+
+func (neat *NEAT) bestGenome() *neatGenome {
+	best := neat.population[0]
+
+	for _, genome := range neat.population {
+		if genome.fitness > best.fitness {
+			best = genome
+		}
+	}
+
+	return best
+}
+
+// GetBestIndividual prints the stats of the last evaluated generation and returns its best network
+func (neat *NEAT) GetBestIndividual() FeedForward {
+	fmt.Print(neat.Stats())
+
+	return neat.Best()
+}
+
+func (neat *NEAT) Best() FeedForward {
+	return newNEATNetwork(neat.bestGenome()).feedForward
+}
+
+// Stats has the number of species and the shape of the best genome as details
+func (neat *NEAT) Stats() Stats {
+	best := neat.bestGenome()
+
+	return Stats{
+		Generation:  neat.generationNumber,
+		BestFitness: best.fitness,
+		Details: []Detail{
+			detail("Species", "%d", len(neat.species)),
+			detail("Nodes", "%d", len(best.nodes)),
+			detail("Connections", "%d", best.enabledConnections()),
+		},
+	}
+}
+
+// Step evolves the next generation, unless none has been evaluated yet, and evaluates it
+func (neat *NEAT) Step() {
+	if neat.evaluated {
+		neat.EvolveGeneration()
+	}
+	neat.EvaluateGeneration()
+	neat.evaluated = true
+}
+
+// SpeciesSizes is the number of genomes in each species of the last evaluated generation, best species first
+func (neat *NEAT) SpeciesSizes() []int {
+	sizes := make([]int, len(neat.species))
+	for i, existing := range neat.species {
+		sizes[i] = len(existing.members)
+	}
+
+	return sizes
+}
+
+func (neat *NEAT) EvolveGeneration() {
+	// species are only formed by evaluating, without them there is nothing to share the next generation between
+	if len(neat.species) == 0 {
+		panic("NEAT has to evaluate a generation before evolving it")
+	}
+
+	// stagnant species are dropped, but the best two always survive so the population can't die out
+	kept := neat.species[:0]
+	for i, existing := range neat.species {
+		if i < 2 || existing.stagnation < neat.config.StagnationLimit {
+			kept = append(kept, existing)
+		}
+	}
+	neat.species = kept
+
+	// shifted so the worst genome is at 0, the same as the adjusted fitness
+	lowest := math.Inf(1)
+	for _, genome := range neat.population {
+		lowest = math.Min(lowest, genome.fitness)
+	}
+
+	fitnessSums := make([]float64, len(neat.species))
+	sizes := make([]int, len(neat.species))
+	for i, existing := range neat.species {
+		for _, genome := range existing.members {
+			fitnessSums[i] += genome.fitness - lowest
+		}
+		sizes[i] = len(existing.members)
+	}
+	offspring := allocateOffspring(fitnessSums, sizes, neat.populationSize)
+
+	newPopulation := make([]*neatGenome, 0, neat.populationSize)
+	for i, existing := range neat.species {
+		if offspring[i] == 0 {
+			continue
+		}
+
+		// the champion of a species that isn't tiny is carried over untouched
+		if len(existing.members) >= 5 {
+			newPopulation = append(newPopulation, existing.members[0].clone())
+			offspring[i]--
+		}
+
+		parents := existing.members[:int(math.Max(1, math.Ceil(float64(len(existing.members))*neat.config.SurvivalThreshold)))]
+		for child := 0; child < offspring[i]; child++ {
+			newPopulation = append(newPopulation, neat.breed(parents))
+		}
+
+		// the next generation is compared against a random member of this one
+		existing.representative = existing.members[rand.Intn(len(existing.members))]
+	}
+
+	neat.population = newPopulation
+	neat.generationNumber++
+}
+
+func (neat *NEAT) breed(parents []*neatGenome) *neatGenome {
+	parent1 := parents[rand.Intn(len(parents))]
+
+	var child *neatGenome
+	if len(parents) > 1 && rand.Float64() < neat.config.CrossoverChance {
+		child = neat.crossover(parent1, parents[rand.Intn(len(parents))])
+	} else {
+		child = parent1.clone()
+	}
+
+	neat.mutate(child)
+
+	return child
+}
+
+// crossover lines up the parents' connections by innovation number, matching ones are picked from either parent
+// and the rest come from the fitter one, so the child has the fitter parent's structure
+func (neat *NEAT) crossover(parent1, parent2 *neatGenome) *neatGenome {
+	if parent2.fitness > parent1.fitness {
+		parent1, parent2 = parent2, parent1
+	}
+
+	child := &neatGenome{
+		nodes:       append([]nodeGene(nil), parent1.nodes...),
+		connections: make([]connectionGene, 0, len(parent1.connections)),
+	}
+
+	other := make(map[int]connectionGene, len(parent2.connections))
+	for _, connection := range parent2.connections {
+		other[connection.Innovation] = connection
+	}
+
+	for _, connection := range parent1.connections {
+		gene := connection
+		if match, ok := other[connection.Innovation]; ok {
+			if rand.Float64() < 0.5 {
+				gene = match
+			}
+
+			gene.Enabled = true
+			if (!connection.Enabled || !match.Enabled) && rand.Float64() < neat.config.KeepDisabledChance {
+				gene.Enabled = false
+			}
+		}
+
+		child.connections = append(child.connections, gene)
+	}
+
+	// biases of nodes both parents have are mixed the same way
+	for i, node := range child.nodes {
+		if match, ok := parent2.node(node.ID); ok && rand.Float64() < 0.5 {
+			child.nodes[i].Bias = match.Bias
+		}
+	}
+
+	return child
+}
+
+func (neat *NEAT) mutate(genome *neatGenome) {
+	for i := range genome.connections {
+		genome.connections[i].Weight = neat.mutateWeight(genome.connections[i].Weight)
+	}
+	for i := range genome.nodes {
+		if genome.nodes[i].Kind != inputNode {
+			genome.nodes[i].Bias = neat.mutateWeight(genome.nodes[i].Bias)
+		}
+	}
+
+	if rand.Float64() < neat.config.AddConnectionChance {
+		neat.mutateAddConnection(genome)
+	}
+	if rand.Float64() < neat.config.AddNodeChance {
+		neat.mutateAddNode(genome)
+	}
+}
+
+func (neat *NEAT) mutateWeight(weight float64) float64 {
+	if rand.Float64() >= neat.config.WeightMutationChance {
+		return weight
+	}
+
+	if rand.Float64() < neat.config.WeightReplaceChance {
+		return randomWeight()
+	}
+
+	return weight + (rand.Float64()*2-1)*neat.config.WeightMutationRate
+}
+
+// mutateAddConnection links two nodes that aren't linked yet, never in a way that would make a cycle
+func (neat *NEAT) mutateAddConnection(genome *neatGenome) {
+	for attempt := 0; attempt < 20; attempt++ {
+		from := genome.nodes[rand.Intn(len(genome.nodes))]
+		to := genome.nodes[rand.Intn(len(genome.nodes))]
+		if from.ID == to.ID || from.Kind == outputNode || to.Kind == inputNode {
+			continue
+		}
+		if genome.hasConnection(from.ID, to.ID) || genome.leadsTo(to.ID, from.ID) {
+			continue
+		}
+
+		genome.addConnection(connectionGene{In: from.ID, Out: to.ID, Weight: randomWeight(), Enabled: true, Innovation: neat.innovation(from.ID, to.ID)})
+		return
+	}
+}
+
+// mutateAddNode splits an enabled connection in two with a new node in the middle, the connection into the node
+// has a weight of 1 and the one out of it keeps the old weight so the network behaves about the same
+func (neat *NEAT) mutateAddNode(genome *neatGenome) {
+	enabled := []int{}
+	for i, connection := range genome.connections {
+		if connection.Enabled {
+			enabled = append(enabled, i)
+		}
+	}
+	if len(enabled) == 0 {
+		return
+	}
+
+	split := &genome.connections[enabled[rand.Intn(len(enabled))]]
+	split.Enabled = false
+	in, out, weight := split.In, split.Out, split.Weight
+
+	// another genome splitting the same connection gets the same node, unless this genome already has it
+	id, ok := neat.splits[split.Innovation]
+	if _, exists := genome.node(id); !ok || exists {
+		id = neat.nextNodeID
+		neat.nextNodeID++
+		neat.splits[split.Innovation] = id
+	}
+
+	genome.nodes = append(genome.nodes, nodeGene{ID: id, Kind: hiddenNode, Activation: neat.config.HiddenActivation})
+	genome.addConnection(connectionGene{In: in, Out: id, Weight: 1, Enabled: true, Innovation: neat.innovation(in, id)})
+	genome.addConnection(connectionGene{In: id, Out: out, Weight: weight, Enabled: true, Innovation: neat.innovation(id, out)})
+}
@@ -0,0 +1,154 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// neatCheckpoint is the state of a NEAT run in a checkpoint
+type neatCheckpoint struct {
+	Generation int               `json:"generation"`
+	Evaluated  bool              `json:"evaluated"`
+	Population []savedNEATGenome `json:"population"`
+	Species    []savedSpecies    `json:"species"`
+
+	NextNodeID     int              `json:"nextNodeID"`
+	NextInnovation int              `json:"nextInnovation"`
+	Innovations    []connectionGene `json:"innovations"`
+	Splits         map[int]int      `json:"splits"`
+}
+
+type savedNEATGenome struct {
+	savedGenome
+	Fitness         float64 `json:"fitness"`
+	AdjustedFitness float64 `json:"adjustedFitness"`
+}
+
+type savedSpecies struct {
+	Representative savedNEATGenome `json:"representative"`
+	// indices into the population
+	Members     []int   `json:"members"`
+	BestFitness float64 `json:"bestFitness"`
+	Stagnation  int     `json:"stagnation"`
+}
+
+func saveNEATGenome(genome *neatGenome) savedNEATGenome {
+	return savedNEATGenome{
+		savedGenome:     savedGenome{Nodes: genome.nodes, Connections: genome.connections},
+		Fitness:         genome.fitness,
+		AdjustedFitness: genome.adjustedFitness,
+	}
+}
+
+func loadNEATGenome(saved savedNEATGenome) (*neatGenome, error) {
+	if err := saved.validate(); err != nil {
+		return nil, err
+	}
+
+	return &neatGenome{
+		nodes:           saved.Nodes,
+		connections:     saved.Connections,
+		fitness:         saved.Fitness,
+		adjustedFitness: saved.AdjustedFitness,
+	}, nil
+}
+
+// Checkpoint writes the population, species and innovation history to w as JSON
+func (neat *NEAT) Checkpoint(w io.Writer) error {
+	checkpoint := neatCheckpoint{
+		Generation:     neat.generationNumber,
+		Evaluated:      neat.evaluated,
+		NextNodeID:     neat.nextNodeID,
+		NextInnovation: neat.nextInnovation,
+		Splits:         neat.splits,
+	}
+
+	index := make(map[*neatGenome]int, len(neat.population))
+	for i, genome := range neat.population {
+		checkpoint.Population = append(checkpoint.Population, saveNEATGenome(genome))
+		index[genome] = i
+	}
+	for _, existing := range neat.species {
+		saved := savedSpecies{
+			Representative: saveNEATGenome(existing.representative),
+			BestFitness:    existing.bestFitness,
+			Stagnation:     existing.stagnation,
+		}
+		for _, member := range existing.members {
+			saved.Members = append(saved.Members, index[member])
+		}
+		checkpoint.Species = append(checkpoint.Species, saved)
+	}
+	for link, innovation := range neat.innovations {
+		checkpoint.Innovations = append(checkpoint.Innovations, connectionGene{In: link[0], Out: link[1], Innovation: innovation})
+	}
+	// in the order they were found, so the same run always writes the same checkpoint
+	sort.Slice(checkpoint.Innovations, func(i, j int) bool {
+		return checkpoint.Innovations[i].Innovation < checkpoint.Innovations[j].Innovation
+	})
+
+	return writeCheckpoint(w, checkpoint)
+}
+
+// Restore continues from a checkpoint written by Checkpoint of a run with the same settings
+func (neat *NEAT) Restore(r io.Reader) error {
+	var checkpoint neatCheckpoint
+	if err := readCheckpoint(r, &checkpoint); err != nil {
+		return err
+	}
+	if len(checkpoint.Population) == 0 {
+		return errors.New("checkpoint has no population")
+	}
+
+	population := make([]*neatGenome, len(checkpoint.Population))
+	for i, saved := range checkpoint.Population {
+		genome, err := loadNEATGenome(saved)
+		if err != nil {
+			return fmt.Errorf("genome %d: %w", i, err)
+		}
+		population[i] = genome
+	}
+
+	allSpecies := make([]*species, len(checkpoint.Species))
+	for i, saved := range checkpoint.Species {
+		representative, err := loadNEATGenome(saved.Representative)
+		if err != nil {
+			return fmt.Errorf("species %d: %w", i, err)
+		}
+
+		restored := &species{representative: representative, bestFitness: saved.BestFitness, stagnation: saved.Stagnation}
+		for _, member := range saved.Members {
+			if member < 0 || member >= len(population) {
+				return fmt.Errorf("species member %d is outside the population", member)
+			}
+			restored.members = append(restored.members, population[member])
+		}
+		allSpecies[i] = restored
+	}
+	if checkpoint.Evaluated && len(allSpecies) == 0 {
+		return errors.New("an evaluated checkpoint needs species")
+	}
+
+	innovations := make(map[[2]int]int, len(checkpoint.Innovations))
+	for _, link := range checkpoint.Innovations {
+		innovations[[2]int{link.In, link.Out}] = link.Innovation
+	}
+	splits := checkpoint.Splits
+	if splits == nil {
+		splits = map[int]int{}
+	}
+
+	neat.population = population
+	neat.populationSize = len(population)
+	neat.species = allSpecies
+	neat.generationNumber = checkpoint.Generation
+	neat.evaluated = checkpoint.Evaluated
+	neat.nextNodeID = checkpoint.NextNodeID
+	neat.nextInnovation = checkpoint.NextInnovation
+	neat.innovations = innovations
+	neat.splits = splits
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// nodeKind is what part of a NEAT network a node is
+type nodeKind int
+
+const (
+	inputNode nodeKind = iota
+	hiddenNode
+	outputNode
+)
+
+// nodeGene is one neuron of a NEAT genome, input nodes only pass their value on and ignore Bias and Activation
+type nodeGene struct {
+	ID         int        `json:"id"`
+	Kind       nodeKind   `json:"kind"`
+	Bias       float64    `json:"bias"`
+	Activation Activation `json:"activation"`
+}
+
+// connectionGene is a weighted link between two nodes, genes with the same Innovation describe the same link
+// in every genome so they can be lined up during crossover
+type connectionGene struct {
+	In         int     `json:"in"`
+	Out        int     `json:"out"`
+	Weight     float64 `json:"weight"`
+	Enabled    bool    `json:"enabled"`
+	Innovation int     `json:"innovation"`
+}
+
+// neatGenome is an individual of a NEAT population, its connections are kept sorted by innovation number
+type neatGenome struct {
+	nodes       []nodeGene
+	connections []connectionGene
+
+	fitness float64
+	// fitness shared with the rest of the genome's species
+	adjustedFitness float64
+}
+
+func (genome *neatGenome) clone() *neatGenome {
+	return &neatGenome{
+		nodes:       append([]nodeGene(nil), genome.nodes...),
+		connections: append([]connectionGene(nil), genome.connections...),
+		fitness:     genome.fitness,
+	}
+}
+
+func (genome *neatGenome) node(id int) (nodeGene, bool) {
+	for _, node := range genome.nodes {
+		if node.ID == id {
+			return node, true
+		}
+	}
+
+	return nodeGene{}, false
+}
+
+func (genome *neatGenome) hasConnection(in, out int) bool {
+	for _, connection := range genome.connections {
+		if connection.In == in && connection.Out == out {
+			return true
+		}
+	}
+
+	return false
+}
+
+// leadsTo reports whether there is a path of enabled or disabled connections from one node to another,
+// disabled ones count since crossover or a later mutation can enable them again
+func (genome *neatGenome) leadsTo(from, to int) bool {
+	visited := map[int]bool{}
+	stack := []int{from}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node == to {
+			return true
+		}
+		if visited[node] {
+			continue
+		}
+		visited[node] = true
+
+		for _, connection := range genome.connections {
+			if connection.In == node {
+				stack = append(stack, connection.Out)
+			}
+		}
+	}
+
+	return false
+}
+
+func (genome *neatGenome) addConnection(connection connectionGene) {
+	index := sort.Search(len(genome.connections), func(i int) bool {
+		return genome.connections[i].Innovation >= connection.Innovation
+	})
+	genome.connections = append(genome.connections, connectionGene{})
+	copy(genome.connections[index+1:], genome.connections[index:])
+	genome.connections[index] = connection
+}
+
+// enabledConnections is the number of connections the network actually uses
+func (genome *neatGenome) enabledConnections() int {
+	count := 0
+	for _, connection := range genome.connections {
+		if connection.Enabled {
+			count++
+		}
+	}
+
+	return count
+}
+
+// compatibility is the distance between two genomes used to split the population into species: the share of
+// genes that don't line up, weighted by whether they are past the end of the other genome, plus the average
+// weight difference of the genes that do
+func (genome *neatGenome) compatibility(other *neatGenome, config *NEATConfig) float64 {
+	excess, disjoint, matching := 0, 0, 0
+	weightDifference := 0.0
+
+	i, j := 0, 0
+	for i < len(genome.connections) && j < len(other.connections) {
+		a, b := genome.connections[i], other.connections[j]
+		switch {
+		case a.Innovation == b.Innovation:
+			matching++
+			weightDifference += math.Abs(a.Weight - b.Weight)
+			i++
+			j++
+		case a.Innovation < b.Innovation:
+			disjoint++
+			i++
+		default:
+			disjoint++
+			j++
+		}
+	}
+	excess = len(genome.connections) - i + len(other.connections) - j
+
+	// small genomes aren't normalized, a few different genes are a big difference for them
+	size := math.Max(float64(len(genome.connections)), float64(len(other.connections)))
+	if size < 20 {
+		size = 1
+	}
+
+	distance := config.ExcessCoefficient*float64(excess)/size + config.DisjointCoefficient*float64(disjoint)/size
+	if matching > 0 {
+		distance += config.WeightCoefficient * weightDifference / float64(matching)
+	}
+
+	return distance
+}
+
+// neatNetwork is a genome compiled into something that can be run, each node is computed after every node
+// feeding into it so a single pass in order gives the output
+type neatNetwork struct {
+	inputs  int
+	outputs []int
+	order   []neatNeuron
+
+	// value of every node, indexed like the genome's nodes
+	values []float64
+	output []float64
+}
+
+type neatNeuron struct {
+	index      int
+	bias       float64
+	activation Activation
+	incoming   []neatLink
+}
+
+type neatLink struct {
+	from   int
+	weight float64
+}
+
+func newNEATNetwork(genome *neatGenome) *neatNetwork {
+	index := make(map[int]int, len(genome.nodes))
+	for i, node := range genome.nodes {
+		index[node.ID] = i
+	}
+
+	network := &neatNetwork{values: make([]float64, len(genome.nodes))}
+	neurons := make([]neatNeuron, len(genome.nodes))
+	waiting := make([]int, len(genome.nodes))
+	outgoing := make([][]int, len(genome.nodes))
+	for i, node := range genome.nodes {
+		neurons[i] = neatNeuron{index: i, bias: node.Bias, activation: node.Activation}
+		switch node.Kind {
+		case inputNode:
+			network.inputs++
+		case outputNode:
+			network.outputs = append(network.outputs, i)
+		}
+	}
+
+	for _, connection := range genome.connections {
+		if !connection.Enabled {
+			continue
+		}
+
+		from, to := index[connection.In], index[connection.Out]
+		neurons[to].incoming = append(neurons[to].incoming, neatLink{from: from, weight: connection.Weight})
+		waiting[to]++
+		outgoing[from] = append(outgoing[from], to)
+	}
+
+	// order the nodes so every node comes after the ones feeding into it, inputs are set directly and left out
+	ready := []int{}
+	for i := range genome.nodes {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		if genome.nodes[i].Kind != inputNode {
+			network.order = append(network.order, neurons[i])
+		}
+
+		for _, to := range outgoing[i] {
+			waiting[to]--
+			if waiting[to] == 0 {
+				ready = append(ready, to)
+			}
+		}
+	}
+
+	network.output = make([]float64, len(network.outputs))
+
+	return network
+}
+
+// feedForward runs the network using its own buffers, the returned slice is overwritten by the next call
+func (network *neatNetwork) feedForward(input []float64) []float64 {
+	if len(input) != network.inputs {
+		panic("Input size does not match network input size")
+	}
+
+	// input nodes always come first in a genome
+	copy(network.values, input)
+
+	var value [1]float64
+	for _, neuron := range network.order {
+		value[0] = neuron.bias
+		for _, link := range neuron.incoming {
+			value[0] += network.values[link.from] * link.weight
+		}
+
+		neuron.activation.apply(value[:])
+		network.values[neuron.index] = value[0]
+	}
+
+	for i, node := range network.outputs {
+		network.output[i] = network.values[node]
+	}
+
+	return network.output
+}
+
+// randomWeight is the starting value of a new connection or bias
+func randomWeight() float64 {
+	return rand.Float64()*2 - 1
+}
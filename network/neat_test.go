@@ -0,0 +1,131 @@
+package network
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestNEATAddNodeReusesInnovations(t *testing.T) {
+	neat := NewNEAT(2, DefaultNEATConfig(1, 1), nil)
+	a, b := neat.population[0], neat.population[1]
+
+	neat.mutateAddNode(a)
+	neat.mutateAddNode(b)
+
+	if len(a.nodes) != 3 || a.nodes[2].ID != b.nodes[2].ID {
+		t.Fatalf("splitting the same connection gave nodes %+v and %+v", a.nodes, b.nodes)
+	}
+	for i := range a.connections {
+		if a.connections[i].Innovation != b.connections[i].Innovation {
+			t.Fatalf("connection %d has innovation %d and %d", i, a.connections[i].Innovation, b.connections[i].Innovation)
+		}
+	}
+	if a.connections[0].Enabled || a.enabledConnections() != 2 {
+		t.Errorf("the split connection should be replaced by two new ones, got %+v", a.connections)
+	}
+}
+
+func TestNEATMutationsStayAcyclic(t *testing.T) {
+	config := DefaultNEATConfig(3, 2)
+	config.AddConnectionChance = 1
+	config.AddNodeChance = 0.5
+	neat := NewNEAT(1, config, nil)
+	genome := neat.population[0]
+
+	for i := 0; i < 200; i++ {
+		neat.mutate(genome)
+	}
+
+	network := newNEATNetwork(genome)
+	if len(network.order) != len(genome.nodes)-3 {
+		t.Fatalf("only %d of %d nodes could be ordered, the genome has a cycle", len(network.order), len(genome.nodes)-3)
+	}
+	for i := 1; i < len(genome.connections); i++ {
+		if genome.connections[i-1].Innovation >= genome.connections[i].Innovation {
+			t.Fatal("connections are not sorted by innovation")
+		}
+	}
+	if output := network.feedForward([]float64{1, 0, -1}); len(output) != 2 {
+		t.Errorf("got %d outputs", len(output))
+	}
+}
+
+func TestNEATCompatibility(t *testing.T) {
+	config := DefaultNEATConfig(2, 1)
+	neat := NewNEAT(2, config, nil)
+	a := neat.population[0]
+
+	if distance := a.compatibility(a.clone(), &config); distance != 0 {
+		t.Errorf("a genome is %v away from its clone", distance)
+	}
+
+	b := a.clone()
+	neat.mutateAddNode(b)
+	// one disabled connection matches, the two new ones are excess
+	if distance := a.compatibility(b, &config); distance != 2*config.ExcessCoefficient {
+		t.Errorf("distance after adding a node is %v", distance)
+	}
+}
+
+func TestNEATCrossoverKeepsFitterStructure(t *testing.T) {
+	neat := NewNEAT(2, DefaultNEATConfig(2, 1), nil)
+	fitter, other := neat.population[0], neat.population[1]
+	neat.mutateAddNode(other)
+	fitter.fitness, other.fitness = 2, 1
+
+	child := neat.crossover(other, fitter)
+	if len(child.nodes) != len(fitter.nodes) || len(child.connections) != len(fitter.connections) {
+		t.Errorf("child has %d nodes and %d connections, the fitter parent has %d and %d",
+			len(child.nodes), len(child.connections), len(fitter.nodes), len(fitter.connections))
+	}
+}
+
+func TestNEATEvolvesAndSaves(t *testing.T) {
+	// reward networks for outputting the sum of their inputs
+	neat := NewNEAT(30, DefaultNEATConfig(2, 1), func(feedForward FeedForward) float64 {
+		return -math.Abs(feedForward([]float64{0.2, 0.3})[0] - 0.5)
+	})
+
+	for generation := 0; generation < 5; generation++ {
+		neat.EvaluateGeneration()
+		total := 0
+		for _, size := range neat.SpeciesSizes() {
+			total += size
+		}
+		if total != 30 {
+			t.Fatalf("generation %d: species hold %d genomes", generation, total)
+		}
+		neat.EvolveGeneration()
+		if len(neat.population) != 30 {
+			t.Fatalf("generation %d: population has %d genomes", generation, len(neat.population))
+		}
+	}
+	neat.EvaluateGeneration()
+
+	path := filepath.Join(t.TempDir(), "genome.json")
+	if err := neat.SaveBest(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFeedForward(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := []float64{0.7, -0.1}
+	want := newNEATNetwork(neat.bestGenome()).feedForward(input)[0]
+	if got := loaded(input)[0]; got != want {
+		t.Errorf("loaded genome gave %v, saved genome gave %v", got, want)
+	}
+}
+
+func TestNEATEvolvingBeforeEvaluatingPanics(t *testing.T) {
+	neat := NewNEAT(10, DefaultNEATConfig(2, 1), func(feedForward FeedForward) float64 { return 0 })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("evolving a generation that was never evaluated didn't panic")
+		}
+	}()
+	neat.EvolveGeneration()
+}
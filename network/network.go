@@ -0,0 +1,168 @@
+package network
+
+import "fmt"
+
+// Network is a fully connected feed forward network. Its weights and biases are stored in one contiguous block,
+// which is what Genome and SetGenome read and write: for each layer its weights, input neuron by input neuron,
+// followed by its biases.
+type Network struct {
+	spec       Spec
+	parameters []float64
+	weights    [][]float64
+	biases     [][]float64
+	// activation of each layer, shared between networks with the same spec
+	activations []Activation
+
+	// buffers for running the network from the goroutine that owns it
+	buffers *inference
+	// float32 copy of the network, built the first time it is run in float32
+	buffers32 *network32
+}
+
+// Layer is a view of one layer of a Network, changing Weights or Biases changes the network
+type Layer struct {
+	Inputs  int
+	Outputs int
+	// the weights from input neuron i are Weights[i*Outputs : (i+1)*Outputs]
+	Weights    []float64
+	Biases     []float64
+	Activation Activation
+}
+
+// NewNetwork creates a network with weights and biases set by the spec's initializers
+func NewNetwork(spec Spec) *Network {
+	if err := spec.validate(); err != nil {
+		panic(err)
+	}
+
+	return newRandomNetwork(spec)
+}
+
+func newRandomNetwork(spec Spec) *Network {
+	network := newNetwork(spec)
+	for layer := range network.weights {
+		spec.WeightInit.initializeWeights(network.weights[layer], spec.Sizes[layer], spec.Sizes[layer+1])
+		spec.BiasInit.initializeBiases(network.biases[layer])
+	}
+
+	return network
+}
+
+// newNetwork lays out a network of the given spec with every weight and bias set to 0
+func newNetwork(spec Spec) *Network {
+	sizes := spec.Sizes
+	count := 0
+	for i := 0; i < len(sizes)-1; i++ {
+		count += sizes[i]*sizes[i+1] + sizes[i+1]
+	}
+
+	parameters := make([]float64, count)
+	weights := make([][]float64, len(sizes)-1)
+	biases := make([][]float64, len(sizes)-1)
+	offset := 0
+	for i := 0; i < len(sizes)-1; i++ {
+		weights[i] = parameters[offset : offset+sizes[i]*sizes[i+1] : offset+sizes[i]*sizes[i+1]]
+		offset += sizes[i] * sizes[i+1]
+		biases[i] = parameters[offset : offset+sizes[i+1] : offset+sizes[i+1]]
+		offset += sizes[i+1]
+	}
+
+	return &Network{spec: spec, parameters: parameters, weights: weights, biases: biases, activations: spec.Activations}
+}
+
+// networkFromLayers copies separately allocated weights and biases into a new network
+func networkFromLayers(weights, biases [][]float64, activations []Activation) *Network {
+	spec := Spec{Sizes: []int{len(weights[0]) / len(biases[0])}, Activations: activations}
+	for _, layer := range biases {
+		spec.Sizes = append(spec.Sizes, len(layer))
+	}
+
+	network := newNetwork(spec)
+	for i := range weights {
+		copy(network.weights[i], weights[i])
+		copy(network.biases[i], biases[i])
+	}
+
+	return network
+}
+
+func (n *Network) Spec() Spec {
+	return n.spec
+}
+
+// Forward runs the network using its own buffers, so it must only be called from one goroutine at a time
+// and the returned slice is overwritten by the next call. Use FeedForward for a copy that can run alongside it.
+func (n *Network) Forward(input []float64) []float64 {
+	return n.feedForward(input)
+}
+
+// FeedForward gives out a FeedForward with its own buffers, it sees later changes to the network's parameters
+func (n *Network) FeedForward() FeedForward {
+	return n.newFeedForward()
+}
+
+// Clone copies the network's parameters into a new network
+func (n *Network) Clone() *Network {
+	clone := newNetwork(n.spec)
+	copy(clone.parameters, n.parameters)
+
+	return clone
+}
+
+func (n *Network) Layers() []Layer {
+	layers := make([]Layer, len(n.weights))
+	for i := range layers {
+		layers[i] = Layer{
+			Inputs:     n.spec.Sizes[i],
+			Outputs:    n.spec.Sizes[i+1],
+			Weights:    n.weights[i],
+			Biases:     n.biases[i],
+			Activation: n.activations[i],
+		}
+	}
+
+	return layers
+}
+
+// ParameterCount is the number of weights and biases in the network
+func (n *Network) ParameterCount() int {
+	return len(n.parameters)
+}
+
+// Genome returns a copy of every weight and bias in the network
+func (n *Network) Genome() []float64 {
+	return append([]float64(nil), n.parameters...)
+}
+
+// SetGenome replaces every weight and bias of the network, laid out the same way Genome returns them
+func (n *Network) SetGenome(genome []float64) error {
+	if len(genome) != len(n.parameters) {
+		return fmt.Errorf("genome has %d parameters, the network has %d", len(genome), len(n.parameters))
+	}
+
+	copy(n.parameters, genome)
+	// the float32 copy is out of date
+	n.buffers32 = nil
+
+	return nil
+}
+
+// feedForward runs the network using the network's own buffers, so it must only be called from one goroutine
+// and the returned slice is overwritten by the next call
+func (n *Network) feedForward(input []float64) []float64 {
+	if n.buffers == nil {
+		n.buffers = newInference(n)
+	}
+
+	return n.buffers.feedForward(input)
+}
+
+// feedForward32 is feedForward run in float32, the copy is made on the first call so the weights must not change after it
+// other than through SetGenome
+func (n *Network) feedForward32(input []float64) []float64 {
+	if n.buffers32 == nil {
+		n.buffers32 = newNetwork32(n)
+	}
+
+	return n.buffers32.feedForward(input)
+}
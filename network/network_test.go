@@ -0,0 +1,61 @@
+package network
+
+import "testing"
+
+func TestNetworkGenome(t *testing.T) {
+	network := NewNetwork(DefaultSpec(5, 4, 3))
+	if count := network.ParameterCount(); count != 5*4+4+4*3+3 {
+		t.Fatalf("ParameterCount is %d", count)
+	}
+
+	input := []float64{1, -1, 0.5, 0, 2}
+	want := append([]float64(nil), network.Forward(input)...)
+
+	other := NewNetwork(DefaultSpec(5, 4, 3))
+	if err := other.SetGenome(network.Genome()); err != nil {
+		t.Fatal(err)
+	}
+	for i, value := range other.Forward(input) {
+		if value != want[i] {
+			t.Fatalf("output %d of the copied genome is %v, want %v", i, value, want[i])
+		}
+	}
+
+	if err := other.SetGenome(make([]float64, 3)); err == nil {
+		t.Error("SetGenome accepted a genome of the wrong size")
+	}
+}
+
+func TestNetworkCloneAndLayers(t *testing.T) {
+	network := NewNetwork(DefaultSpec(3, 2))
+	clone := network.Clone()
+
+	layer := network.Layers()[0]
+	if layer.Inputs != 3 || layer.Outputs != 2 || len(layer.Weights) != 6 || len(layer.Biases) != 2 {
+		t.Fatalf("unexpected layer %+v", layer)
+	}
+
+	// layers are views, the clone is not
+	layer.Biases[0] += 1
+	if network.Genome()[6] != layer.Biases[0] {
+		t.Error("changing the layer didn't change the network")
+	}
+	if clone.Genome()[6] == layer.Biases[0] {
+		t.Error("changing the network changed its clone")
+	}
+}
+
+func TestSetGenomeUpdatesFloat32(t *testing.T) {
+	network := NewNetwork(DefaultSpec(3, 2))
+	input := []float64{1, 2, 3}
+	before := append([]float64(nil), network.feedForward32(input)...)
+
+	if err := network.SetGenome(make([]float64, network.ParameterCount())); err != nil {
+		t.Fatal(err)
+	}
+
+	after := network.feedForward32(input)
+	if after[0] == before[0] || after[0] != 0.5 {
+		t.Errorf("float32 output after zeroing the genome is %v", after[0])
+	}
+}
@@ -0,0 +1,206 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Niching splits the population of a GeneticAlgorithm into species of similar networks that share their fitness,
+// so one strategy can't take over the whole population before the others have had a chance to improve
+type Niching struct {
+	// individuals closer than this to a species' representative join it, see genomeDistance
+	Threshold float64
+	// when above 0 the threshold is nudged every generation to keep about this many species
+	TargetSpecies int
+}
+
+// niche is one species of a GeneticAlgorithm's population
+type niche struct {
+	// individual new members are compared against, a member of the previous generation
+	representative *individual
+	// best first once the generation is evaluated
+	members []*individual
+}
+
+// UseNiching turns on speciation and fitness sharing for the following generations
+func (ga *GeneticAlgorithm) UseNiching(niching Niching) {
+	if niching.Threshold <= 0 {
+		panic("Niching needs a threshold above 0")
+	}
+
+	ga.niching = &niching
+}
+
+// genomeDistance is the root mean square difference between the weights and biases of two networks of the same spec
+func genomeDistance(a, b *Network) float64 {
+	sum := 0.0
+	for i, value := range a.parameters {
+		difference := value - b.parameters[i]
+		sum += difference * difference
+	}
+
+	return math.Sqrt(sum / float64(len(a.parameters)))
+}
+
+// speciate assigns the evaluated generation to species and nudges the threshold towards TargetSpecies, once per
+// generation
+func (ga *GeneticAlgorithm) speciate() {
+	ga.assignSpecies()
+
+	if ga.niching.TargetSpecies > 0 {
+		if len(ga.niches) < ga.niching.TargetSpecies {
+			ga.niching.Threshold *= 0.95
+		} else if len(ga.niches) > ga.niching.TargetSpecies {
+			ga.niching.Threshold *= 1.05
+		}
+	}
+}
+
+// assignSpecies puts every individual into the first species whose representative is close enough, or a new one
+func (ga *GeneticAlgorithm) assignSpecies() {
+	for _, existing := range ga.niches {
+		existing.members = existing.members[:0]
+	}
+
+	for _, individual := range ga.population {
+		var found *niche
+		for _, existing := range ga.niches {
+			if genomeDistance(individual.Network, existing.representative.Network) < ga.niching.Threshold {
+				found = existing
+				break
+			}
+		}
+
+		if found == nil {
+			found = &niche{representative: individual}
+			ga.niches = append(ga.niches, found)
+		}
+		found.members = append(found.members, individual)
+	}
+
+	alive := ga.niches[:0]
+	for _, existing := range ga.niches {
+		if len(existing.members) == 0 {
+			continue
+		}
+
+		sort.Slice(existing.members, func(i, j int) bool {
+			return existing.members[i].fitness > existing.members[j].fitness
+		})
+		alive = append(alive, existing)
+	}
+	ga.niches = alive
+
+	sort.Slice(ga.niches, func(i, j int) bool {
+		return ga.niches[i].members[0].fitness > ga.niches[j].members[0].fitness
+	})
+}
+
+// SpeciesSizes is the number of individuals in each species of the last evaluated generation, best species first,
+// it is empty when niching is off
+func (ga *GeneticAlgorithm) SpeciesSizes() []int {
+	sizes := make([]int, len(ga.niches))
+	for i, existing := range ga.niches {
+		sizes[i] = len(existing.members)
+	}
+
+	return sizes
+}
+
+// speciesDetail is the number of species and the sizes of the largest ones
+func (ga *GeneticAlgorithm) speciesDetail() Detail {
+	sizes := ga.SpeciesSizes()
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+
+	if len(sizes) > 10 {
+		return detail("Species", "%d %v...", len(sizes), sizes[:10])
+	}
+
+	return detail("Species", "%d %v", len(sizes), sizes)
+}
+
+// allocateOffspring splits populationSize children between species by their shared fitness, which is the species'
+// fitness sum divided by its size since every member's fitness is shared with the rest of its species. The sums must
+// not be negative, shift the fitness so the worst individual is at 0 first. Species are expected best first.
+func allocateOffspring(fitnessSums []float64, sizes []int, populationSize int) []int {
+	if len(sizes) == 0 {
+		panic("There are no species to allocate offspring to, evaluate a generation first")
+	}
+
+	shares := make([]float64, len(sizes))
+	sum := 0.0
+	for i := range sizes {
+		shares[i] = fitnessSums[i] / float64(sizes[i])
+		sum += shares[i]
+	}
+
+	offspring := make([]int, len(sizes))
+	allocated := 0
+	for i := range sizes {
+		if sum > 0 {
+			offspring[i] = int(shares[i] / sum * float64(populationSize))
+		} else {
+			// every individual did equally badly, give every species the same
+			offspring[i] = populationSize / len(sizes)
+		}
+		allocated += offspring[i]
+	}
+
+	// rounding down leaves a few children over, they go to the best species
+	for i := 0; allocated < populationSize; i = (i + 1) % len(offspring) {
+		offspring[i]++
+		allocated++
+	}
+
+	return offspring
+}
+
+// evolveNiches breeds each species on its own, parents are picked by tournament among the species' members
+func (ga *GeneticAlgorithm) evolveNiches() {
+	// fitness can be negative, shift it so the worst individual is at 0 before sharing it out
+	lowest := math.Inf(1)
+	for _, individual := range ga.population {
+		lowest = math.Min(lowest, individual.fitness)
+	}
+
+	fitnessSums := make([]float64, len(ga.niches))
+	sizes := make([]int, len(ga.niches))
+	for i, existing := range ga.niches {
+		for _, individual := range existing.members {
+			fitnessSums[i] += individual.fitness - lowest
+		}
+		sizes[i] = len(existing.members)
+	}
+	offspring := allocateOffspring(fitnessSums, sizes, ga.populationSize)
+
+	newPopulation := make([]*individual, 0, ga.populationSize)
+	for i, existing := range ga.niches {
+		if offspring[i] == 0 {
+			continue
+		}
+
+		// the champion of a species that isn't tiny is carried over untouched
+		if len(existing.members) >= 5 {
+			champion := newEmptyIndividual(ga.spec)
+			copy(champion.parameters, existing.members[0].parameters)
+			champion.fitness = existing.members[0].fitness
+			champion.objective = existing.members[0].objective
+			newPopulation = append(newPopulation, champion)
+			offspring[i]--
+		}
+
+		for child := 0; child < offspring[i]; child++ {
+			parent1 := tournamentSelection(existing.members, 3)
+			parent2 := tournamentSelection(existing.members, 3)
+			individual := ga.crossoverParents(parent1, parent2)
+			ga.mutateIndividual(individual)
+			newPopulation = append(newPopulation, individual)
+		}
+
+		// the next generation is compared against a random member of this one
+		existing.representative = existing.members[rand.Intn(len(existing.members))]
+	}
+
+	ga.population = newPopulation
+}
@@ -0,0 +1,110 @@
+package network
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNichingSplitsAndSharesPopulation(t *testing.T) {
+	ga := NewGeneticAlgoritm(40, DefaultSpec(3, 2), 0.1, 0.1, func(feedForward FeedForward) float64 {
+		return feedForward([]float64{1, 0, -1})[0]
+	})
+
+	// two clusters of networks far apart from each other
+	for i, individual := range ga.population {
+		genome := make([]float64, individual.ParameterCount())
+		for j := range genome {
+			genome[j] = float64(i % 2 * 10)
+		}
+		if err := individual.SetGenome(genome); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ga.UseNiching(Niching{Threshold: 1})
+	ga.EvaluateGeneration()
+
+	sizes := ga.SpeciesSizes()
+	if len(sizes) != 2 || sizes[0] != 20 || sizes[1] != 20 {
+		t.Fatalf("expected two species of 20, got %v", sizes)
+	}
+
+	ga.EvolveGeneration()
+	if len(ga.population) != 40 {
+		t.Fatalf("population has %d individuals after evolving", len(ga.population))
+	}
+
+	ga.EvaluateGeneration()
+	total := 0
+	for _, size := range ga.SpeciesSizes() {
+		total += size
+	}
+	if total != 40 {
+		t.Errorf("species hold %d individuals", total)
+	}
+}
+
+func TestNichingChampionsKeepTheirFitness(t *testing.T) {
+	ga := NewGeneticAlgoritm(20, DefaultSpec(3, 2), 0.1, 0.1, func(feedForward FeedForward) float64 {
+		return feedForward([]float64{1, 0, -1})[0]
+	})
+	// one species, so the best individual is its champion
+	ga.UseNiching(Niching{Threshold: math.Inf(1)})
+	ga.EvaluateGeneration()
+	best := ga.bestIndividual()
+
+	ga.EvolveGeneration()
+	for _, individual := range ga.population {
+		if genomeDistance(individual.Network, best.Network) == 0 {
+			if individual.fitness != best.fitness {
+				t.Errorf("champion has fitness %v after being carried over, it had %v", individual.fitness, best.fitness)
+			}
+			return
+		}
+	}
+	t.Error("the champion wasn't carried over")
+}
+
+func TestAllocateOffspring(t *testing.T) {
+	tests := []struct {
+		fitnessSums []float64
+		sizes       []int
+		offspring   []int
+	}{
+		// shares of 10, 10 and 0
+		{[]float64{30, 10, 0}, []int{3, 1, 2}, []int{5, 5, 0}},
+		// nobody did better than the worst, the child left over from sharing evenly goes to the first species
+		{[]float64{0, 0, 0}, []int{1, 4, 2}, []int{4, 3, 3}},
+		// rounding down leaves children for the best species
+		{[]float64{1, 1, 1}, []int{1, 1, 1}, []int{4, 3, 3}},
+	}
+
+	for _, test := range tests {
+		offspring := allocateOffspring(test.fitnessSums, test.sizes, 10)
+		for i := range offspring {
+			if offspring[i] != test.offspring[i] {
+				t.Errorf("sums %v of sizes %v: got %v children, expected %v", test.fitnessSums, test.sizes, offspring, test.offspring)
+				break
+			}
+		}
+	}
+}
+
+func TestGenomeDistance(t *testing.T) {
+	a := NewNetwork(DefaultSpec(2, 1))
+	b := a.Clone()
+	if distance := genomeDistance(a, b); distance != 0 {
+		t.Errorf("a network is %v away from its clone", distance)
+	}
+
+	genome := b.Genome()
+	for i := range genome {
+		genome[i] += 2
+	}
+	if err := b.SetGenome(genome); err != nil {
+		t.Fatal(err)
+	}
+	if distance := genomeDistance(a, b); math.Abs(distance-2) > 1e-9 {
+		t.Errorf("moving every parameter by 2 gave a distance of %v", distance)
+	}
+}
@@ -0,0 +1,117 @@
+package network
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// evaluateBehavior plays a game and returns its fitness along with a description of how the network played
+type evaluateBehavior func(FeedForward) (float64, []float64)
+
+// Novelty makes a GeneticAlgorithm select individuals for how different their behavior is from what has been seen
+// before instead of for their fitness, so it can't get stuck farming a deceptive fitness function
+type Novelty struct {
+	// novelty is the average distance to this many of the closest behaviors in the population and archive
+	Neighbors int
+	// behaviors at least this novel are added to the archive
+	ArchiveThreshold float64
+	// chance of any other behavior being added to the archive
+	ArchiveChance float64
+}
+
+type noveltySearch struct {
+	Novelty
+	evaluate evaluateBehavior
+	// behaviors of every individual that was novel enough when it was seen
+	archive [][]float64
+}
+
+// UseNovelty switches selection to novelty search for the following generations, it replaces group evaluation.
+// The best individual is still the one with the highest fitness from evaluate.
+func (ga *GeneticAlgorithm) UseNovelty(novelty Novelty, evaluate evaluateBehavior) {
+	if novelty.Neighbors < 1 {
+		panic("Novelty search needs at least 1 neighbor")
+	}
+
+	ga.novelty = &noveltySearch{Novelty: novelty, evaluate: evaluate}
+}
+
+// ArchiveSize is the number of behaviors in the novelty archive
+func (ga *GeneticAlgorithm) ArchiveSize() int {
+	if ga.novelty == nil {
+		return 0
+	}
+
+	return len(ga.novelty.archive)
+}
+
+// behaviorDistance is the euclidean distance between two behaviors
+func behaviorDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i, value := range a {
+		difference := value - b[i]
+		sum += difference * difference
+	}
+
+	return math.Sqrt(sum)
+}
+
+// evaluateBehaviorMedian plays a game for every entry of fitnessTrack and keeps the median game's fitness and behavior
+func evaluateBehaviorMedian(evaluate evaluateBehavior, feedForward FeedForward, individual *individual, fitnessTrack []float64) {
+	behaviors := make(map[float64][]float64, len(fitnessTrack))
+	individual.objective = medianFitness(func(feedForward FeedForward) float64 {
+		fitness, behavior := evaluate(feedForward)
+		behaviors[fitness] = behavior
+		return fitness
+	}, feedForward, fitnessTrack)
+	individual.behavior = behaviors[individual.objective]
+}
+
+func (ga *GeneticAlgorithm) evaluateNovelty() {
+	fitnessTrack := make([]float64, 5)
+	for _, individual := range ga.population {
+		evaluateBehaviorMedian(ga.novelty.evaluate, ga.feedForwardOf(individual), individual, fitnessTrack)
+	}
+
+	distances := make([]float64, 0, len(ga.population)+len(ga.novelty.archive))
+	for _, individual := range ga.population {
+		distances = distances[:0]
+		for _, other := range ga.population {
+			if other != individual {
+				distances = append(distances, behaviorDistance(individual.behavior, other.behavior))
+			}
+		}
+		for _, behavior := range ga.novelty.archive {
+			distances = append(distances, behaviorDistance(individual.behavior, behavior))
+		}
+
+		sort.Float64s(distances)
+		neighbors := distances[:int(math.Min(float64(ga.novelty.Neighbors), float64(len(distances))))]
+		individual.fitness = 0
+		for _, distance := range neighbors {
+			individual.fitness += distance / float64(len(neighbors))
+		}
+	}
+
+	// the archive only grows after every novelty is worked out so the order of the population doesn't matter
+	for _, individual := range ga.population {
+		if individual.fitness >= ga.novelty.ArchiveThreshold || rand.Float64() < ga.novelty.ArchiveChance {
+			ga.novelty.archive = append(ga.novelty.archive, individual.behavior)
+		}
+	}
+}
+
+// performance is what an individual is judged on outside of selection
+func (ga *GeneticAlgorithm) performance(individual *individual) float64 {
+	if ga.novelty != nil {
+		return individual.objective
+	}
+
+	return individual.fitness
+}
+
+// noveltyDetails are the novelty of the best individual and the size of the archive
+func (ga *GeneticAlgorithm) noveltyDetails(best *individual) []Detail {
+	return []Detail{detail("Novelty", "%f", best.fitness), detail("Archive", "%d", len(ga.novelty.archive))}
+}
@@ -0,0 +1,37 @@
+package network
+
+import "testing"
+
+func TestNoveltyRewardsUnusualBehavior(t *testing.T) {
+	ga := NewGeneticAlgoritm(6, DefaultSpec(1, 1), 0, 0, nil)
+	// the behavior is the network's output, every network but the last gives about the same one
+	for i, individual := range ga.population {
+		genome := []float64{0, 0}
+		if i == len(ga.population)-1 {
+			genome[1] = 5
+		}
+		if err := individual.SetGenome(genome); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ga.UseNovelty(Novelty{Neighbors: 2, ArchiveThreshold: 0.3}, func(feedForward FeedForward) (float64, []float64) {
+		output := feedForward([]float64{1})[0]
+		return -output, []float64{output}
+	})
+	ga.EvaluateGeneration()
+
+	unusual := ga.population[len(ga.population)-1]
+	for _, individual := range ga.population[:len(ga.population)-1] {
+		if individual.fitness >= unusual.fitness {
+			t.Errorf("common behavior has novelty %v, the unusual one has %v", individual.fitness, unusual.fitness)
+		}
+	}
+	if ga.ArchiveSize() != 1 {
+		t.Errorf("archive has %d behaviors, only the unusual one is novel enough", ga.ArchiveSize())
+	}
+	// the best individual is still picked by fitness, which the unusual network is worst at
+	if ga.bestIndividual() == unusual {
+		t.Error("the most novel individual was picked as the best")
+	}
+}
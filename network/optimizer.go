@@ -0,0 +1,119 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Optimizer is a training algorithm the trainer can run without knowing which one it is. Settings and evaluation
+// functions are given to the algorithm's constructor, a checkpoint only holds the state of the search so it must be
+// restored into an optimizer made with the same settings.
+type Optimizer interface {
+	// Step makes the next generation from the last evaluated one, if there is one, and evaluates it
+	Step()
+	// Best runs the best network of the last evaluated generation with its own buffers
+	Best() FeedForward
+	Stats() Stats
+	// SaveBest writes the best network of the last evaluated generation to path as JSON
+	SaveBest(path string) error
+	Checkpoint(w io.Writer) error
+	Restore(r io.Reader) error
+}
+
+var (
+	_ Optimizer = (*GeneticAlgorithm)(nil)
+	_ Optimizer = (*IslandModel)(nil)
+	_ Optimizer = (*NEAT)(nil)
+	_ Optimizer = (*MAPElites)(nil)
+	_ Optimizer = (*EvolutionStrategy)(nil)
+	_ Optimizer = (*CMAES)(nil)
+	_ Optimizer = (*DifferentialEvolution)(nil)
+)
+
+// Stats describes the last evaluated generation of an optimizer
+type Stats struct {
+	Generation int
+	// best fitness of the generation, not the best ever seen
+	BestFitness float64
+	// values particular to the algorithm, like species counts or step sizes, in the order they are printed
+	Details []Detail
+}
+
+type Detail struct {
+	Name  string
+	Value string
+}
+
+// detail formats a value into a Detail
+func detail(name, format string, values ...interface{}) Detail {
+	return Detail{Name: name, Value: fmt.Sprintf(format, values...)}
+}
+
+func (stats Stats) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Generation: %d, Fitness: %f", stats.Generation, stats.BestFitness)
+	for _, detail := range stats.Details {
+		fmt.Fprintf(&builder, ", %s: %s", detail.Name, detail.Value)
+	}
+
+	return builder.String()
+}
+
+// savedIndividual is how individuals are stored in checkpoints
+type savedIndividual struct {
+	Parameters []float64 `json:"parameters"`
+	Fitness    float64   `json:"fitness"`
+	Objective  float64   `json:"objective,omitempty"`
+	Behavior   []float64 `json:"behavior,omitempty"`
+}
+
+// saveIndividuals keeps nil entries as entries without parameters
+func saveIndividuals(individuals []*individual) []savedIndividual {
+	saved := make([]savedIndividual, len(individuals))
+	for i, individual := range individuals {
+		if individual == nil {
+			continue
+		}
+
+		saved[i] = savedIndividual{
+			Parameters: individual.parameters,
+			Fitness:    individual.fitness,
+			Objective:  individual.objective,
+			Behavior:   individual.behavior,
+		}
+	}
+
+	return saved
+}
+
+// loadIndividuals rebuilds individuals of the spec, nil entries are kept as nil
+func loadIndividuals(spec Spec, saved []savedIndividual) ([]*individual, error) {
+	individuals := make([]*individual, len(saved))
+	for i, entry := range saved {
+		if entry.Parameters == nil {
+			continue
+		}
+
+		individual := newEmptyIndividual(spec)
+		if err := individual.SetGenome(entry.Parameters); err != nil {
+			return nil, fmt.Errorf("individual %d: %w", i, err)
+		}
+		individual.fitness = entry.Fitness
+		individual.objective = entry.Objective
+		individual.behavior = entry.Behavior
+		individuals[i] = individual
+	}
+
+	return individuals, nil
+}
+
+// writeCheckpoint and readCheckpoint are the JSON encoding every optimizer's checkpoint uses
+func writeCheckpoint(w io.Writer, checkpoint interface{}) error {
+	return json.NewEncoder(w).Encode(checkpoint)
+}
+
+func readCheckpoint(r io.Reader, checkpoint interface{}) error {
+	return json.NewDecoder(r).Decode(checkpoint)
+}
@@ -0,0 +1,114 @@
+package network
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestOptimizerCheckpointRoundTrip(t *testing.T) {
+	spec := Spec{Sizes: []int{2, 3, 1}, Activations: []Activation{{Function: Tanh}, {Function: Identity}}}
+	evaluate := func(feedForward FeedForward) float64 {
+		return -math.Abs(feedForward([]float64{1, 1})[0] - 0.5)
+	}
+	evaluateBehavior := func(feedForward FeedForward) (float64, []float64) {
+		output := feedForward([]float64{1, 1})[0]
+		return -math.Abs(output - 0.5), []float64{output}
+	}
+
+	optimizers := map[string]func() Optimizer{
+		"genetic": func() Optimizer {
+			ga := NewGeneticAlgoritm(20, spec, 0.1, 0.3, evaluate)
+			ga.UseNiching(Niching{Threshold: 0.5, TargetSpecies: 3})
+			return ga
+		},
+		"novelty": func() Optimizer {
+			ga := NewGeneticAlgoritm(20, spec, 0.1, 0.3, evaluate)
+			ga.UseNovelty(Novelty{Neighbors: 3, ArchiveThreshold: 0.1, ArchiveChance: 0.1}, evaluateBehavior)
+			return ga
+		},
+		"islands": func() Optimizer {
+			islands := []*GeneticAlgorithm{NewGeneticAlgoritm(10, spec, 0.1, 0.3, evaluate), NewGeneticAlgoritm(10, spec, 0.1, 0.3, evaluate)}
+			model, err := NewIslandModel(islands, RingMigration, 1, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return model
+		},
+		"neat": func() Optimizer {
+			return NewNEAT(20, DefaultNEATConfig(2, 1), evaluate)
+		},
+		"map-elites": func() Optimizer {
+			return NewMAPElites(10, spec, []BehaviorDimension{{Index: 0, Min: -1, Max: 1, Bins: 5}}, 0.1, 0.3, evaluateBehavior)
+		},
+		"es": func() Optimizer {
+			config := DefaultEvolutionStrategyConfig()
+			config.Pairs = 5
+			return NewEvolutionStrategy(spec, config, evaluate)
+		},
+		"cmaes": func() Optimizer {
+			config := DefaultCMAESConfig()
+			config.Restart = BIPOP
+			return NewCMAES(spec, config, evaluate)
+		},
+		"jade": func() Optimizer {
+			return NewDifferentialEvolution(10, spec, JADE, 0.5, 0.9, evaluate)
+		},
+	}
+
+	for name, newOptimizer := range optimizers {
+		optimizer := newOptimizer()
+		for i := 0; i < 3; i++ {
+			optimizer.Step()
+		}
+
+		var checkpoint bytes.Buffer
+		if err := optimizer.Checkpoint(&checkpoint); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		restored := newOptimizer()
+		if err := restored.Restore(bytes.NewReader(checkpoint.Bytes())); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		// the restored optimizer holds the same state, so it writes the same checkpoint
+		var again bytes.Buffer
+		if err := restored.Checkpoint(&again); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(checkpoint.Bytes(), again.Bytes()) {
+			t.Errorf("%s: checkpoint changed after restoring", name)
+		}
+
+		if restored.Stats().String() != optimizer.Stats().String() {
+			t.Errorf("%s: stats went from %q to %q", name, optimizer.Stats(), restored.Stats())
+		}
+		if best, restoredBest := optimizer.Best()([]float64{1, 1})[0], restored.Best()([]float64{1, 1})[0]; best != restoredBest {
+			t.Errorf("%s: best network went from %v to %v", name, best, restoredBest)
+		}
+
+		// and carries on from where it was
+		restored.Step()
+		if generation := restored.Stats().Generation; generation != optimizer.Stats().Generation+1 {
+			t.Errorf("%s: restored optimizer went on to generation %d", name, generation)
+		}
+	}
+}
+
+func TestRestoreRejectsOtherSpec(t *testing.T) {
+	evaluate := func(feedForward FeedForward) float64 { return 0 }
+
+	ga := NewGeneticAlgoritm(10, DefaultSpec(2, 3, 1), 0.1, 0.3, evaluate)
+	ga.Step()
+
+	var checkpoint bytes.Buffer
+	if err := ga.Checkpoint(&checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewGeneticAlgoritm(10, DefaultSpec(2, 4, 1), 0.1, 0.3, evaluate)
+	if err := other.Restore(&checkpoint); err == nil {
+		t.Error("restored a checkpoint of networks with a different spec")
+	}
+}
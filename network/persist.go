@@ -0,0 +1,179 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// savedNetwork is the JSON file format networks are saved in
+type savedNetwork struct {
+	Weights [][]float64 `json:"weights"`
+	Biases  [][]float64 `json:"biases"`
+	// missing in networks saved before activations could be chosen, those used the default ones
+	Activations []Activation `json:"activations,omitempty"`
+}
+
+// savedGenome is the JSON file format NEAT genomes are saved in
+type savedGenome struct {
+	Nodes       []nodeGene       `json:"nodes"`
+	Connections []connectionGene `json:"connections"`
+}
+
+// SaveBest writes the best individual of the last evaluated generation to path as JSON
+func (ga *GeneticAlgorithm) SaveBest(path string) error {
+	return ga.bestIndividual().Save(path)
+}
+
+// Save writes the network to path as JSON
+func (n *Network) Save(path string) error {
+	data, err := json.Marshal(savedNetwork{Weights: n.weights, Biases: n.biases, Activations: n.activations})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// SaveBest writes the best genome of the last evaluated generation to path as JSON
+func (neat *NEAT) SaveBest(path string) error {
+	best := neat.bestGenome()
+	data, err := json.Marshal(savedGenome{Nodes: best.nodes, Connections: best.connections})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadFeedForward reads a network saved by SaveBest or Save, either a layered network or a NEAT genome
+func LoadFeedForward(path string) (FeedForward, error) {
+	feedForward, _, _, err := LoadFeedForwardSized(path)
+
+	return feedForward, err
+}
+
+// LoadFeedForwardSized is LoadFeedForward that also returns how many inputs the network reads and how many outputs
+// it gives, so it can be checked against what it is going to be fed
+func LoadFeedForwardSized(path string) (feedForward FeedForward, inputs, outputs int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var genome savedGenome
+	if err := json.Unmarshal(data, &genome); err != nil {
+		return nil, 0, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	if genome.Connections != nil {
+		if err := genome.validate(); err != nil {
+			return nil, 0, 0, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, node := range genome.Nodes {
+			switch node.Kind {
+			case inputNode:
+				inputs++
+			case outputNode:
+				outputs++
+			}
+		}
+
+		return newNEATNetwork(&neatGenome{nodes: genome.Nodes, connections: genome.Connections}).feedForward, inputs, outputs, nil
+	}
+
+	network, err := LoadNetwork(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	sizes := network.Spec().Sizes
+	return network.FeedForward(), sizes[0], sizes[len(sizes)-1], nil
+}
+
+// LoadNetwork reads a network saved by SaveBest or Save
+func LoadNetwork(path string) (*Network, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved savedNetwork
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if saved.Activations == nil && len(saved.Biases) > 0 {
+		saved.Activations = DefaultSpec(make([]int, len(saved.Biases)+1)...).Activations
+	}
+
+	if err := saved.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return networkFromLayers(saved.Weights, saved.Biases, saved.Activations), nil
+}
+
+// validate checks that the layers of a loaded network fit together
+func (saved *savedNetwork) validate() error {
+	if len(saved.Weights) == 0 || len(saved.Weights) != len(saved.Biases) {
+		return errors.New("network needs the same number of weight and bias layers")
+	}
+
+	for i := range saved.Weights {
+		if len(saved.Biases[i]) == 0 || len(saved.Weights[i])%len(saved.Biases[i]) != 0 {
+			return fmt.Errorf("layer %d has %d weights for %d neurons", i+1, len(saved.Weights[i]), len(saved.Biases[i]))
+		}
+
+		if i > 0 && len(saved.Weights[i])/len(saved.Biases[i]) != len(saved.Biases[i-1]) {
+			return fmt.Errorf("layer %d expects %d inputs but the layer before has %d neurons", i+1, len(saved.Weights[i])/len(saved.Biases[i]), len(saved.Biases[i-1]))
+		}
+	}
+
+	spec := Spec{Sizes: []int{len(saved.Weights[0]) / len(saved.Biases[0])}, Activations: saved.Activations}
+	for _, biases := range saved.Biases {
+		spec.Sizes = append(spec.Sizes, len(biases))
+	}
+	if err := spec.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validate checks that a loaded genome's connections link nodes it has without making a cycle
+func (saved *savedGenome) validate() error {
+	kinds := make(map[int]nodeKind, len(saved.Nodes))
+	for i, node := range saved.Nodes {
+		if _, ok := kinds[node.ID]; ok {
+			return fmt.Errorf("node %d appears twice", node.ID)
+		}
+		if node.Kind == inputNode && i > 0 && saved.Nodes[i-1].Kind != inputNode {
+			return errors.New("input nodes must come before every other node")
+		}
+		if node.Kind != inputNode {
+			if _, ok := activationNames[node.Activation.Function]; !ok || node.Activation.Function == Softmax {
+				return fmt.Errorf("node %d has an activation function NEAT nodes can't use", node.ID)
+			}
+		}
+		kinds[node.ID] = node.Kind
+	}
+
+	genome := &neatGenome{nodes: saved.Nodes, connections: saved.Connections}
+	for _, connection := range saved.Connections {
+		in, inOK := kinds[connection.In]
+		out, outOK := kinds[connection.Out]
+		if !inOK || !outOK {
+			return fmt.Errorf("connection %d links a node that doesn't exist", connection.Innovation)
+		}
+		if out == inputNode || in == outputNode {
+			return fmt.Errorf("connection %d goes into an input or out of an output", connection.Innovation)
+		}
+		if genome.leadsTo(connection.Out, connection.In) {
+			return fmt.Errorf("connection %d makes a cycle", connection.Innovation)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package network
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSavedNetworkKeepsActivations(t *testing.T) {
+	spec := Spec{
+		Sizes:       []int{5, 4, 3},
+		Activations: []Activation{{Function: ELU, Alpha: 0.5}, {Function: Softmax}},
+	}
+	ga := NewGeneticAlgoritm(2, spec, 0, 0, nil)
+	path := filepath.Join(t.TempDir(), "network.json")
+
+	if err := ga.SaveBest(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFeedForward(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := []float64{0.3, -0.7, 1, 0, -2}
+	want := ga.bestIndividual().newFeedForward()(input)
+	got := loaded(input)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("output %d: loaded network gave %v, saved network gave %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadFeedForwardSized(t *testing.T) {
+	dir := t.TempDir()
+
+	ga := NewGeneticAlgoritm(2, DefaultSpec(5, 4, 3), 0, 0, nil)
+	if err := ga.SaveBest(filepath.Join(dir, "network.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	neat := NewNEAT(10, DefaultNEATConfig(6, 2), func(feedForward FeedForward) float64 { return 1 })
+	neat.EvaluateGeneration()
+	if err := neat.SaveBest(filepath.Join(dir, "genome.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		file            string
+		inputs, outputs int
+	}{{"network.json", 5, 3}, {"genome.json", 6, 2}} {
+		feedForward, inputs, outputs, err := LoadFeedForwardSized(filepath.Join(dir, test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inputs != test.inputs || outputs != test.outputs {
+			t.Errorf("%s: loaded %d inputs and %d outputs, expected %d and %d", test.file, inputs, outputs, test.inputs, test.outputs)
+		}
+		if output := feedForward(make([]float64, inputs)); len(output) != outputs {
+			t.Errorf("%s: gave %d outputs", test.file, len(output))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Spec describes the shape of a network
+type Spec struct {
+	// number of neurons in each layer, including the input and output layers
+	Sizes []int
+	// activation of every layer after the input layer
+	Activations []Activation
+
+	// how new networks start out, the zero value draws both from [-1, 1]
+	WeightInit Initializer
+	BiasInit   Initializer
+}
+
+// DefaultSpec uses leaky ReLU for the hidden layers and sigmoid for the output layer
+func DefaultSpec(sizes ...int) Spec {
+	spec := Spec{Sizes: sizes, Activations: make([]Activation, len(sizes)-1)}
+	for i := range spec.Activations {
+		spec.Activations[i] = Activation{Function: LeakyReLU, Alpha: 0.01}
+	}
+	spec.Activations[len(spec.Activations)-1] = Activation{Function: Sigmoid}
+	spec.WeightInit = Uniform
+	spec.BiasInit = Uniform
+
+	return spec
+}
+
+func (spec Spec) validate() error {
+	if len(spec.Sizes) < 2 {
+		return errors.New("network needs at least an input and an output layer")
+	}
+
+	for i, size := range spec.Sizes {
+		if size < 1 {
+			return fmt.Errorf("layer %d has %d neurons", i, size)
+		}
+	}
+
+	if len(spec.Activations) != len(spec.Sizes)-1 {
+		return fmt.Errorf("network with %d layers needs %d activations, got %d", len(spec.Sizes), len(spec.Sizes)-1, len(spec.Activations))
+	}
+
+	for i, activation := range spec.Activations {
+		if _, ok := activationNames[activation.Function]; !ok {
+			return fmt.Errorf("layer %d has unknown activation function %d", i+1, int(activation.Function))
+		}
+	}
+
+	if err := spec.WeightInit.validate(false); err != nil {
+		return err
+	}
+
+	return spec.BiasInit.validate(true)
+}
@@ -0,0 +1,274 @@
+package snake
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	MinArenaSnakes = 2
+	MaxArenaSnakes = 8
+)
+
+// arenaStart is where a snake starts in the arena, snakes on the left face right and snakes on the right face left
+type arenaStart struct {
+	left bool
+	y    int
+}
+
+// starting spots in the order they are handed out, so small arenas still spread the snakes out
+var arenaStarts = [MaxArenaSnakes]arenaStart{
+	{left: true, y: 1},
+	{left: false, y: 8},
+	{left: false, y: 1},
+	{left: true, y: 8},
+	{left: true, y: 4},
+	{left: false, y: 5},
+	{left: false, y: 3},
+	{left: true, y: 6},
+}
+
+var arenaColors = [MaxArenaSnakes]color.RGBA{
+	{255, 0, 0, 255},
+	{0, 128, 255, 255},
+	{255, 160, 0, 255},
+	{255, 0, 255, 255},
+	{0, 255, 255, 255},
+	{255, 255, 255, 255},
+	{128, 255, 0, 255},
+	{160, 80, 0, 255},
+}
+
+// Arena is a game where several snakes share one board and food, each controlled by its own Input.
+// A snake dies running into another snake's body, and when two heads meet the shorter snake dies, or both if
+// they are the same length. The game is over once at most one snake is left. Loop detection is not used in the arena
+// since a snake repeating its own state doesn't mean the board is repeating.
+type Arena struct {
+	// board, food and rules shared by the snakes, Game.Snake is not used
+	Game   Game
+	Snakes []*Snake
+	Inputs []Input
+	IsOver bool
+
+	// move each snake died on, 0 while it is alive
+	DiedAt []int
+}
+
+func NewArena(config Config, inputs []Input) (*Arena, error) {
+	if len(inputs) < MinArenaSnakes || len(inputs) > MaxArenaSnakes {
+		return nil, fmt.Errorf("arena needs %d to %d snakes, got %d", MinArenaSnakes, MaxArenaSnakes, len(inputs))
+	}
+
+	arena := &Arena{
+		Inputs: inputs,
+		Snakes: make([]*Snake, len(inputs)),
+		DiedAt: make([]int, len(inputs)),
+	}
+	arena.Game.Config = config
+
+	for i := range inputs {
+		start := arenaSnake(arenaStarts[i])
+		for _, location := range start.locations() {
+			if arena.Game.IsWall(location.X, location.Y) {
+				return nil, fmt.Errorf("arena start %d is blocked by a wall", i+1)
+			}
+		}
+	}
+
+	arena.Reset()
+
+	return arena, nil
+}
+
+func arenaSnake(start arenaStart) Snake {
+	snake := Snake{
+		Head:            Location{X: 3, Y: start.y},
+		Tail:            []Location{{X: 2, Y: start.y}, {X: 1, Y: start.y}, {X: 0, Y: start.y}},
+		Direction:       RightDirection,
+		TargetDirection: RightDirection,
+	}
+
+	if !start.left {
+		snake.Head.X = BoardWidth - 4
+		for i := range snake.Tail {
+			snake.Tail[i].X = BoardWidth - 3 + i
+		}
+		snake.Direction = LeftDirection
+		snake.TargetDirection = LeftDirection
+	}
+
+	return snake
+}
+
+func (a *Arena) Reset() {
+	for i := range a.Snakes {
+		snake := arenaSnake(arenaStarts[i])
+		a.Snakes[i] = &snake
+		a.DiedAt[i] = 0
+	}
+
+	a.Game.Snakes = a.Snakes
+	a.Game.Moves = 0
+	a.Game.Foods = a.Game.Foods[:0]
+	a.Game.nextFixedFood = 0
+	a.Game.fillFood()
+	a.IsOver = false
+
+	for _, input := range a.Inputs {
+		input.Reset()
+	}
+}
+
+// Alive counts the snakes still in the game
+func (a *Arena) Alive() int {
+	alive := 0
+	for _, snake := range a.Snakes {
+		if !snake.IsDead {
+			alive++
+		}
+	}
+
+	return alive
+}
+
+// Placements ranks the snakes by how long they survived, 1 being the winner, snakes dying on the same move share a place
+func (a *Arena) Placements() []int {
+	placements := make([]int, len(a.Snakes))
+	for i := range a.Snakes {
+		placements[i] = 1
+		for j := range a.Snakes {
+			if a.outlived(j, i) {
+				placements[i]++
+			}
+		}
+	}
+
+	return placements
+}
+
+// outlived reports whether snake i survived longer than snake j
+func (a *Arena) outlived(i, j int) bool {
+	if a.DiedAt[j] == 0 {
+		return false
+	}
+
+	return a.DiedAt[i] == 0 || a.DiedAt[i] > a.DiedAt[j]
+}
+
+// Step advances every living snake by one move
+func (a *Arena) Step() {
+	if a.IsOver {
+		return
+	}
+
+	for i, snake := range a.Snakes {
+		if !snake.IsDead {
+			a.Inputs[i].HandleInput(&a.Game, snake)
+		}
+	}
+
+	a.Game.Moves++
+
+	for _, snake := range a.Snakes {
+		if snake.IsDead {
+			continue
+		}
+
+		snake.advance()
+		if a.Game.Config.Wrap {
+			snake.Head.X, snake.Head.Y = WrapLocation(snake.Head.X, snake.Head.Y)
+		}
+		snake.settle()
+
+		if !InBounds(snake.Head.X, snake.Head.Y) || a.Game.IsWall(snake.Head.X, snake.Head.Y) {
+			snake.Die(HitWall)
+		}
+	}
+
+	// collisions between snakes are checked once everyone has moved, dead snakes are removed on the next move
+	for i, snake := range a.Snakes {
+		if snake.IsDead && a.DiedAt[i] != 0 {
+			continue
+		}
+
+		for j, other := range a.Snakes {
+			if i == j || (other.IsDead && a.DiedAt[j] != 0) {
+				continue
+			}
+
+			if other.ContainsLocation(snake.Head.X, snake.Head.Y, false) {
+				snake.Die(HitSnake)
+			} else if other.Head == snake.Head && len(other.Tail) >= len(snake.Tail) {
+				snake.Die(HeadOn)
+			}
+		}
+	}
+
+	a.Game.expireFood()
+
+	for i, snake := range a.Snakes {
+		if snake.IsDead {
+			if a.DiedAt[i] == 0 {
+				a.DiedAt[i] = a.Game.Moves
+			}
+			continue
+		}
+
+		if index := a.Game.FoodAt(snake.Head.X, snake.Head.Y); index != -1 {
+			a.Game.eatFood(snake, index)
+			a.Game.fillFood()
+		}
+
+		if a.Game.Config.MaxMoves > 0 && a.Game.Moves >= a.Game.Config.MaxMoves {
+			snake.Die(MoveCapped)
+			a.DiedAt[i] = a.Game.Moves
+		}
+	}
+
+	if a.Alive() <= 1 {
+		a.IsOver = true
+	}
+}
+
+func (a *Arena) Poll() {
+	for _, input := range a.Inputs {
+		input.Poll()
+	}
+}
+
+func (a *Arena) Over() bool {
+	return a.IsOver
+}
+
+func (a *Arena) Draw(screen *ebiten.Image) {
+	gameBoard := a.Game.drawBoard()
+
+	for i, snake := range a.Snakes {
+		if snake.IsDead {
+			continue
+		}
+
+		// tails are drawn darker than the heads
+		headColor := arenaColors[i]
+		tailColor := color.RGBA{headColor.R / 2, headColor.G / 2, headColor.B / 2, 255}
+		DrawSquare(gameBoard, snake.Head.X, snake.Head.Y, headColor)
+		for _, tail := range snake.Tail {
+			DrawSquare(gameBoard, tail.X, tail.Y, tailColor)
+		}
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(squareSize, squareSize)
+	screen.DrawImage(gameBoard, op)
+}
+
+func (a *Arena) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return a.Game.Layout(outsideWidth, outsideHeight)
+}
+
+// ArenaColor is the color snake index is drawn with
+func ArenaColor(index int) color.RGBA {
+	return arenaColors[index%MaxArenaSnakes]
+}
@@ -0,0 +1,114 @@
+package snake
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestArena starts an arena with two snakes placed on the board and steering as given
+func newTestArena(t *testing.T, snakes [2]Snake, directions [2]int) *Arena {
+	inputs := []Input{&scriptedInput{Directions: []int{directions[0]}}, &scriptedInput{Directions: []int{directions[1]}}}
+	arena, err := NewArena(Config{Level: farFood}, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range snakes {
+		*arena.Snakes[i] = snakes[i]
+	}
+
+	return arena
+}
+
+// line is a snake with its head on head and its tail trailing length squares behind it
+func line(head Location, direction, length int) Snake {
+	snake := Snake{Head: head, Direction: direction, TargetDirection: direction}
+	behind := (direction + 2) % 4
+	for i := 0; i < length; i++ {
+		head = head.moved(behind)
+		snake.Tail = append(snake.Tail, head)
+	}
+
+	return snake
+}
+
+func TestArenaStartsFit(t *testing.T) {
+	inputs := make([]Input, MaxArenaSnakes)
+	for i := range inputs {
+		inputs[i] = &scriptedInput{Directions: []int{UpDirection}}
+	}
+	arena, err := NewArena(Config{}, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, snake := range arena.Snakes {
+		for _, location := range snake.locations() {
+			if !InBounds(location.X, location.Y) {
+				t.Errorf("snake %d starts off the board at %v", i, location)
+			}
+			for j, other := range arena.Snakes {
+				if i != j && other.ContainsLocation(location.X, location.Y, true) {
+					t.Errorf("snakes %d and %d both start on %v", i, j, location)
+				}
+			}
+		}
+	}
+
+	if _, err := NewArena(Config{}, inputs[:1]); err == nil {
+		t.Error("arena started with a single snake")
+	}
+}
+
+func TestArenaBodyHit(t *testing.T) {
+	// the first snake runs into the end of the second's tail, which is still there after it moves
+	arena := newTestArena(t, [2]Snake{
+		line(Location{X: 4, Y: 1}, RightDirection, 3),
+		line(Location{X: 5, Y: 3}, DownDirection, 3),
+	}, [2]int{RightDirection, DownDirection})
+	arena.Step()
+
+	if arena.Snakes[0].DeathCause != HitSnake || arena.Snakes[1].IsDead {
+		t.Errorf("snakes died of %s and %s, expected only the first to hit the other", arena.Snakes[0].DeathCause, arena.Snakes[1].DeathCause)
+	}
+	if !arena.IsOver || arena.DiedAt[0] != 1 {
+		t.Errorf("arena over %v with the first snake dead on move %d", arena.IsOver, arena.DiedAt[0])
+	}
+	if placements := arena.Placements(); !reflect.DeepEqual(placements, []int{2, 1}) {
+		t.Errorf("placements are %v, expected the second snake to win", placements)
+	}
+}
+
+func TestArenaHeadOn(t *testing.T) {
+	// same length, both heads land on (5, 1) and both snakes die
+	arena := newTestArena(t, [2]Snake{
+		line(Location{X: 4, Y: 1}, RightDirection, 3),
+		line(Location{X: 6, Y: 1}, LeftDirection, 3),
+	}, [2]int{RightDirection, LeftDirection})
+	arena.Step()
+
+	if arena.Snakes[0].DeathCause != HeadOn || arena.Snakes[1].DeathCause != HeadOn {
+		t.Errorf("snakes died of %s and %s, expected both head-on", arena.Snakes[0].DeathCause, arena.Snakes[1].DeathCause)
+	}
+	if placements := arena.Placements(); !reflect.DeepEqual(placements, []int{1, 1}) {
+		t.Errorf("placements are %v, expected a draw", placements)
+	}
+
+	// the longer snake survives
+	arena = newTestArena(t, [2]Snake{
+		line(Location{X: 4, Y: 1}, RightDirection, 3),
+		line(Location{X: 5, Y: 2}, UpDirection, 4),
+	}, [2]int{RightDirection, UpDirection})
+	arena.Step()
+
+	if arena.Snakes[0].DeathCause != HeadOn || arena.Snakes[1].IsDead {
+		t.Errorf("snakes died of %s and %s, expected only the shorter head-on", arena.Snakes[0].DeathCause, arena.Snakes[1].DeathCause)
+	}
+}
+
+func TestPlacements(t *testing.T) {
+	arena := &Arena{Snakes: make([]*Snake, 5), DiedAt: []int{3, 0, 3, 5, 1}}
+	if placements := arena.Placements(); !reflect.DeepEqual(placements, []int{3, 1, 3, 2, 5}) {
+		t.Errorf("placements are %v, expected [3 1 3 2 5]", placements)
+	}
+}
@@ -0,0 +1,187 @@
+package snake
+
+import "image/color"
+
+type FoodType int
+
+const (
+	// NormalFood grows the snake by one
+	NormalFood FoodType = iota
+	// BonusFood grows the snake by Config.BonusGrowth
+	BonusFood
+	// PoisonFood shrinks the snake by one, down to a single tail segment
+	PoisonFood
+
+	FoodTypeCount = 3
+)
+
+func (foodType FoodType) String() string {
+	switch foodType {
+	case NormalFood:
+		return "normal"
+	case BonusFood:
+		return "bonus"
+	case PoisonFood:
+		return "poison"
+	}
+
+	return "unknown"
+}
+
+func (foodType FoodType) color() color.RGBA {
+	switch foodType {
+	case BonusFood:
+		return color.RGBA{255, 215, 0, 255}
+	case PoisonFood:
+		return color.RGBA{160, 32, 240, 255}
+	}
+
+	return color.RGBA{0, 255, 0, 255}
+}
+
+type Food struct {
+	Location
+	Type FoodType
+	// move on which the food disappears, 0 if it never does
+	ExpiresAt int
+}
+
+// foodCount is the number of food items kept on the board
+func (g *Game) foodCount() int {
+	if g.Config.FoodCount <= 0 {
+		return 1
+	}
+
+	return g.Config.FoodCount
+}
+
+// randomFoodType rolls the type of a new food item from the configured chances
+func (g *Game) randomFoodType() FoodType {
+	roll := g.float64()
+	if roll < g.Config.BonusFoodChance {
+		return BonusFood
+	}
+	if roll < g.Config.BonusFoodChance+g.Config.PoisonFoodChance {
+		return PoisonFood
+	}
+
+	return NormalFood
+}
+
+// FoodAt returns the index of the food at the location, or -1 if there is none
+func (g *Game) FoodAt(x, y int) int {
+	for index, food := range g.Foods {
+		if food.X == x && food.Y == y {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// NearestFood finds the closest food of the given type to the location by moves needed to reach it
+func (g *Game) NearestFood(from Location, foodType FoodType) (Food, bool) {
+	best := -1
+	bestDistance := 0
+	for index, food := range g.Foods {
+		if food.Type != foodType {
+			continue
+		}
+
+		distance := g.Distance(from, food.Location)
+		if best == -1 || distance < bestDistance {
+			best = index
+			bestDistance = distance
+		}
+	}
+
+	if best == -1 {
+		return Food{}, false
+	}
+
+	return g.Foods[best], true
+}
+
+// Distance is the number of moves between two locations ignoring obstacles, going around the edges on a wrapped board
+func (g *Game) Distance(a, b Location) int {
+	dx := abs(a.X - b.X)
+	dy := abs(a.Y - b.Y)
+	if g.Config.Wrap && BoardWidth-dx < dx {
+		dx = BoardWidth - dx
+	}
+	if g.Config.Wrap && BoardHeight-dy < dy {
+		dy = BoardHeight - dy
+	}
+
+	return dx + dy
+}
+
+func (g *Game) isFree(x, y int) bool {
+	return !g.IsSnake(x, y) && !g.IsWall(x, y) && g.FoodAt(x, y) == -1
+}
+
+// fillFood places food until the board holds the configured amount
+func (g *Game) fillFood() {
+	for len(g.Foods) < g.foodCount() {
+		if !g.PlaceFood() {
+			return
+		}
+	}
+}
+
+// expireFood removes food that has been on the board too long and replaces it
+func (g *Game) expireFood() {
+	foods := g.Foods[:0]
+	for _, food := range g.Foods {
+		if food.ExpiresAt == 0 || food.ExpiresAt > g.Moves {
+			foods = append(foods, food)
+		}
+	}
+
+	if len(foods) != len(g.Foods) {
+		g.Foods = foods
+		g.fillFood()
+
+		// the board changed for every snake, so earlier states are no longer loops
+		for _, snake := range g.Snakes {
+			if !snake.IsDead {
+				snake.forgetStates()
+			}
+		}
+	}
+}
+
+// eatFood applies the effect of the food at index to the snake and removes it from the board
+func (g *Game) eatFood(snake *Snake, index int) {
+	food := g.Foods[index]
+	g.Foods = append(g.Foods[:index], g.Foods[index+1:]...)
+
+	snake.Eaten[food.Type]++
+	switch food.Type {
+	case NormalFood:
+		snake.Growth++
+	case BonusFood:
+		snake.Growth += g.Config.bonusGrowth()
+	case PoisonFood:
+		snake.shrink(1)
+	}
+
+	// the board changed, so earlier states are no longer loops
+	snake.forgetStates()
+}
+
+func (config Config) bonusGrowth() int {
+	if config.BonusGrowth <= 0 {
+		return 3
+	}
+
+	return config.BonusGrowth
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
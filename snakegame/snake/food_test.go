@@ -0,0 +1,126 @@
+package snake
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b  Location
+		wrap  bool
+		moves int
+	}{
+		{Location{X: 1, Y: 1}, Location{X: 4, Y: 3}, false, 5},
+		{Location{X: 0, Y: 0}, Location{X: BoardWidth - 1, Y: 0}, false, BoardWidth - 1},
+		// one step across the edge either way
+		{Location{X: 0, Y: 0}, Location{X: BoardWidth - 1, Y: 0}, true, 1},
+		{Location{X: 2, Y: 0}, Location{X: 2, Y: BoardHeight - 1}, true, 1},
+		// both axes wrap, and one that is shorter across the middle doesn't
+		{Location{X: 1, Y: 1}, Location{X: BoardWidth - 1, Y: BoardHeight - 1}, true, 4},
+		{Location{X: 3, Y: 3}, Location{X: 5, Y: 4}, true, 3},
+	}
+
+	for _, test := range tests {
+		game := &Game{Config: Config{Wrap: test.wrap}}
+		if moves := game.Distance(test.a, test.b); moves != test.moves {
+			t.Errorf("wrap %v: distance from %v to %v is %d, expected %d", test.wrap, test.a, test.b, moves, test.moves)
+		}
+		if moves := game.Distance(test.b, test.a); moves != test.moves {
+			t.Errorf("wrap %v: distance from %v to %v is %d, expected %d", test.wrap, test.b, test.a, moves, test.moves)
+		}
+	}
+}
+
+func TestNearestFoodWraps(t *testing.T) {
+	from := Location{X: 0, Y: 5}
+	near := Food{Location: Location{X: 3, Y: 5}}
+	acrossEdge := Food{Location: Location{X: BoardWidth - 1, Y: 5}}
+	bonus := Food{Location: Location{X: 1, Y: 5}, Type: BonusFood}
+
+	for _, wrap := range []bool{false, true} {
+		game := &Game{Config: Config{Wrap: wrap}, Foods: []Food{near, acrossEdge, bonus}}
+
+		expected := near
+		if wrap {
+			expected = acrossEdge
+		}
+		if food, ok := game.NearestFood(from, NormalFood); !ok || food != expected {
+			t.Errorf("wrap %v: nearest food is %v, expected %v", wrap, food, expected)
+		}
+	}
+
+	game := &Game{Foods: []Food{near}}
+	if _, ok := game.NearestFood(from, PoisonFood); ok {
+		t.Error("found poison food on a board without any")
+	}
+}
+
+// eatAhead puts food of the type right in front of the default snake and moves onto it
+func eatAhead(game *Game, foodType FoodType) {
+	game.Foods = []Food{{Location: Location{X: 6, Y: 5}, Type: foodType}}
+	game.Input = &scriptedInput{Directions: []int{RightDirection}}
+	game.Step()
+}
+
+func TestBonusFoodGrowth(t *testing.T) {
+	for _, test := range []struct{ bonusGrowth, growth int }{{0, 3}, {1, 1}, {2, 2}} {
+		game := newTestGame(Config{BonusGrowth: test.bonusGrowth, Level: farFood})
+		eatAhead(game, BonusFood)
+
+		if game.Snake.Eaten[BonusFood] != 1 || game.Snake.Growth != test.growth {
+			t.Errorf("bonus growth %d: ate %v and is growing by %d, expected %d", test.bonusGrowth, game.Snake.Eaten, game.Snake.Growth, test.growth)
+		}
+
+		// the tail keeps its end until the growth is used up, at most 3 more moves right fit on the board
+		playOut(game, test.growth)
+		if len(game.Snake.Tail) != 3+test.growth {
+			t.Errorf("bonus growth %d: tail is %d long, expected %d", test.bonusGrowth, len(game.Snake.Tail), 3+test.growth)
+		}
+	}
+}
+
+func TestPoisonFoodShrinks(t *testing.T) {
+	game := newTestGame(Config{Level: farFood})
+	eatAhead(game, PoisonFood)
+	if game.Snake.Eaten[PoisonFood] != 1 || len(game.Snake.Tail) != 2 || game.Snake.Growth != 0 {
+		t.Errorf("after poison the tail is %d long and growing by %d, expected 2 and 0", len(game.Snake.Tail), game.Snake.Growth)
+	}
+
+	// never below a single tail segment
+	game = newTestGame(Config{Level: farFood})
+	game.Snake.Tail = game.Snake.Tail[:1]
+	eatAhead(game, PoisonFood)
+	if len(game.Snake.Tail) != 1 || game.Snake.IsDead {
+		t.Errorf("after poison a short snake's tail is %d long, dead %v", len(game.Snake.Tail), game.Snake.IsDead)
+	}
+}
+
+func TestFoodExpires(t *testing.T) {
+	// only bonus and poison food expire
+	game := newTestGame(Config{FoodLifetime: 5, Level: farFood})
+	if game.Foods[0].ExpiresAt != 0 {
+		t.Errorf("normal food expires at %d", game.Foods[0].ExpiresAt)
+	}
+
+	game = newTestGame(Config{FoodLifetime: 5, BonusFoodChance: 1, DetectLoops: true, Level: farFood})
+	game.Input = &scriptedInput{Directions: circling}
+	if len(game.Foods) != 1 || game.Foods[0].Type != BonusFood || game.Foods[0].ExpiresAt != 5 {
+		t.Fatalf("food is %v, expected bonus food expiring on move 5", game.Foods)
+	}
+
+	// another snake on the board has its states forgotten with the game's snake when the board changes
+	other := &Snake{Head: Location{X: 0, Y: 0}, Tail: []Location{{X: 0, Y: 1}}}
+	other.recordState()
+	game.Snakes = append(game.Snakes, other)
+
+	playOut(game, 4)
+	if game.Foods[0].ExpiresAt != 5 || len(game.Snake.seenStates) != 4 {
+		t.Errorf("food changed before it expired, %v", game.Foods)
+	}
+
+	game.Step()
+	if len(game.Foods) != 1 || game.Foods[0].ExpiresAt != 10 {
+		t.Errorf("food is %v after expiring, expected new food expiring on move 10", game.Foods)
+	}
+	if len(game.Snake.seenStates) != 1 || len(other.seenStates) != 0 {
+		t.Errorf("snakes remember %d and %d states after the food expired", len(game.Snake.seenStates), len(other.seenStates))
+	}
+}
@@ -3,10 +3,8 @@ package snake
 import (
 	"image/color"
 	"math/rand"
-	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
@@ -27,55 +25,193 @@ type Location struct {
 	Y int
 }
 
+// Config holds the rule variations a game can be played with
+type Config struct {
+	// end the game once the snake repeats a state (head, direction and tail) since its last apple,
+	// with the food in the same place a deterministic snake would otherwise circle until it starves.
+	// only for snakes that always pick the same move in the same state, one that picks moves at random can repeat a
+	// state and still get out of it, so it would be killed for nothing
+	DetectLoops bool
+	// hard limit on the number of moves in a game, 0 for no limit
+	MaxMoves int
+	// leaving one edge of the board enters from the opposite edge instead of hitting the wall
+	Wrap bool
+	// static walls and start/food squares, nil for an empty board
+	Level *Level
+
+	// number of food items on the board at once, 0 means 1
+	FoodCount int
+	// chance of each new food item being bonus or poison food instead of normal food
+	BonusFoodChance  float64
+	PoisonFoodChance float64
+	// how much bonus food grows the snake, 0 means 3
+	BonusGrowth int
+	// moves before bonus and poison food disappears, 0 to keep it until eaten
+	FoodLifetime int
+}
+
 type Game struct {
-	Snake  Snake
-	Food   Location
+	Snake Snake
+	// every snake on the board, in a normal game this is just Snake
+	Snakes []*Snake
+	Foods  []Food
 	IsOver bool
 	Input  Input
+	Config Config
 
-	// TODO game probably shouldn't know about this, should allow inherited inputs to control pace?
-	// NEED neural input to be able to go as fast as it can compute
-	nextTick time.Time
-	TickMs   int64
-
-	AutoRestart bool
-
-	Random *rand.Source
+	// source of randomness for food, the global source is used when nil
+	Random *rand.Rand
 
 	Moves int
+	// number of moves the snake's head ended on each square, indexed [y][x]
+	Visits [BoardHeight][BoardWidth]int
+
+	// index of the next fixed food square to use from the level
+	nextFixedFood int
 }
 
 func (g *Game) Reset() {
+	start := snakeStartingAt(defaultStart)
+	if g.Config.Level != nil {
+		start = g.Config.Level.startingSnake()
+	}
+
 	g.Snake = Snake{
-		Head: Location{X: 5, Y: 5},
-		Tail: []Location{
-			{X: 4, Y: 5},
-			{X: 3, Y: 5},
-			{X: 2, Y: 5},
-		},
+		Head:            start[0],
+		Tail:            start[1:],
 		TargetDirection: RightDirection,
 		Direction:       RightDirection,
 	}
 
-	g.PlaceFood()
+	g.Snakes = []*Snake{&g.Snake}
+
+	if g.Input != nil {
+		g.Input.Reset()
+	}
 
 	// Reset everything
 	g.Moves = 0
+	g.Visits = [BoardHeight][BoardWidth]int{}
 	g.IsOver = false
 	g.Snake.IsDead = false
-	g.Snake.AteFood = false
+	g.Snake.Growth = 0
+
+	g.Foods = g.Foods[:0]
+	g.nextFixedFood = 0
+	g.fillFood()
+}
+
+// PlaceFood adds one food item to the board, returning false if there is no free square for it
+func (g *Game) PlaceFood() bool {
+	food := Food{Type: g.randomFoodType()}
+	if food.Type != NormalFood && g.Config.FoodLifetime > 0 {
+		food.ExpiresAt = g.Moves + g.Config.FoodLifetime
+	}
+
+	// Use the level's fixed food squares in order, skipping any that are taken
+	if g.Config.Level != nil {
+		fixedFood := g.Config.Level.FixedFood
+		for i := 0; i < len(fixedFood); i++ {
+			location := fixedFood[(g.nextFixedFood+i)%len(fixedFood)]
+			if g.isFree(location.X, location.Y) {
+				food.Location = location
+				g.Foods = append(g.Foods, food)
+				g.nextFixedFood = (g.nextFixedFood + i + 1) % len(fixedFood)
+				return true
+			}
+		}
+	}
+
+	// Place food at random location not occupied by snake, walls or other food
+	free := make([]Location, 0, BoardWidth*BoardHeight)
+	for x := 0; x < BoardWidth; x++ {
+		for y := 0; y < BoardHeight; y++ {
+			if g.isFree(x, y) {
+				free = append(free, Location{X: x, Y: y})
+			}
+		}
+	}
+
+	if len(free) == 0 {
+		return false
+	}
+
+	food.Location = free[g.intn(len(free))]
+	g.Foods = append(g.Foods, food)
+
+	return true
+}
+
+// Seed gives the game its own random source so games with the same seed get the same food
+func (g *Game) Seed(seed int64) {
+	g.Random = rand.New(rand.NewSource(seed))
+}
+
+func (g *Game) intn(n int) int {
+	if g.Random == nil {
+		return rand.Intn(n)
+	}
+
+	return g.Random.Intn(n)
+}
+
+func (g *Game) float64() float64 {
+	if g.Random == nil {
+		return rand.Float64()
+	}
+
+	return g.Random.Float64()
+}
+
+// IsSnake reports whether any living snake on the board is at the location
+func (g *Game) IsSnake(x, y int) bool {
+	for _, snake := range g.Snakes {
+		if !snake.IsDead && snake.ContainsLocation(x, y, true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWall reports whether there is a level wall at the location, the board edges are not counted
+func (g *Game) IsWall(x, y int) bool {
+	return g.Config.Level != nil && g.Config.Level.IsWall(x, y)
 }
 
-func (g *Game) PlaceFood() {
-	// Place food at random location not occupied by snake
-	for {
-		g.Food.X = rand.Intn(BoardWidth)
-		g.Food.Y = rand.Intn(BoardHeight)
+// IsSafeDirection reports whether the snake can move towards direction without dying on its next move: it isn't
+// reversing, and the square ahead isn't off the board (unless the board wraps), a wall or part of a living snake.
+// The end of another snake's tail counts as free when it isn't growing since it moves away first, where the other
+// snakes' heads go next isn't guessed at.
+func (g *Game) IsSafeDirection(player *Snake, direction int) bool {
+	if !player.IsLegalDirection(direction) {
+		return false
+	}
+
+	next := player.Head.moved(direction)
+	if g.Config.Wrap {
+		next.X, next.Y = WrapLocation(next.X, next.Y)
+	}
+	if !InBounds(next.X, next.Y) || g.IsWall(next.X, next.Y) {
+		return false
+	}
+
+	// a snake runs into the end of its own tail before it moves away
+	if player.ContainsLocation(next.X, next.Y, false) {
+		return false
+	}
+
+	for _, other := range g.Snakes {
+		if other == player || other.IsDead || !other.ContainsLocation(next.X, next.Y, true) {
+			continue
+		}
 
-		if !g.Snake.ContainsLocation(g.Food.X, g.Food.Y, true) {
-			break
+		if other.Growth > 0 || other.Head == next || other.Tail[len(other.Tail)-1] != next {
+			return false
 		}
 	}
+
+	return true
 }
 
 func DrawSquare(mainImage *ebiten.Image, x, y int, color color.RGBA) {
@@ -83,11 +219,7 @@ func DrawSquare(mainImage *ebiten.Image, x, y int, color color.RGBA) {
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	gameBoard := ebiten.NewImage(BoardWidth, BoardHeight)
-	gameBoard.Fill(color.RGBA{0, 0, 0, 255})
-
-	// Draw food
-	DrawSquare(gameBoard, g.Food.X, g.Food.Y, color.RGBA{0, 255, 0, 255})
+	gameBoard := g.drawBoard()
 
 	// Draw snake
 	DrawSquare(gameBoard, g.Snake.Head.X, g.Snake.Head.Y, color.RGBA{255, 0, 0, 255})
@@ -101,58 +233,97 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	screen.DrawImage(gameBoard, op)
 }
 
-func (g *Game) Update() error {
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
-		g.Reset()
+// drawBoard draws everything but the snakes onto a new image with one pixel per square
+func (g *Game) drawBoard() *ebiten.Image {
+	gameBoard := ebiten.NewImage(BoardWidth, BoardHeight)
+	gameBoard.Fill(color.RGBA{0, 0, 0, 255})
+
+	// Draw walls
+	for x := 0; x < BoardWidth; x++ {
+		for y := 0; y < BoardHeight; y++ {
+			if g.IsWall(x, y) {
+				DrawSquare(gameBoard, x, y, color.RGBA{128, 128, 128, 255})
+			}
+		}
 	}
 
-	if g.Snake.IsDead {
-		g.IsOver = true
+	// Draw food
+	for _, food := range g.Foods {
+		DrawSquare(gameBoard, food.X, food.Y, food.Type.color())
 	}
 
+	return gameBoard
+}
+
+func (g *Game) Poll() {
+	g.Input.Poll()
+}
+
+func (g *Game) Over() bool {
+	return g.IsOver
+}
+
+// Step makes exactly one move, pacing is left to whatever calls it (see Runner)
+func (g *Game) Step() {
 	if g.IsOver {
-		if g.AutoRestart {
-			g.Reset()
-		}
-		return nil
+		return
 	}
 
 	g.Input.HandleInput(g, &g.Snake)
 
-	// check if current time is past next tick
-	if time.Now().Before(g.nextTick) {
-		return nil
-	}
-	g.nextTick = time.Now().Add(time.Duration(g.TickMs) * time.Millisecond)
-
 	g.Moves++
 
-	g.Snake.Update()
+	g.Snake.advance()
+	if g.Config.Wrap {
+		g.Snake.Head.X, g.Snake.Head.Y = WrapLocation(g.Snake.Head.X, g.Snake.Head.Y)
+	}
+	g.Snake.settle()
 
 	// Check if snake hit wall
-	if g.Snake.Head.X < 0 || g.Snake.Head.X >= BoardWidth || g.Snake.Head.Y < 0 || g.Snake.Head.Y >= BoardHeight {
-		g.Snake.IsDead = true
+	if !InBounds(g.Snake.Head.X, g.Snake.Head.Y) || g.IsWall(g.Snake.Head.X, g.Snake.Head.Y) {
+		g.Snake.Die(HitWall)
+	} else {
+		g.Visits[g.Snake.Head.Y][g.Snake.Head.X]++
 	}
 
+	g.expireFood()
+
 	// Check if snake ate food
-	if g.Snake.Head.X == g.Food.X && g.Snake.Head.Y == g.Food.Y {
+	if index := g.FoodAt(g.Snake.Head.X, g.Snake.Head.Y); index != -1 && !g.Snake.IsDead {
 		// Generate new food location
-		g.Snake.AteFood = true
-		g.PlaceFood()
+		g.eatFood(&g.Snake, index)
+		g.fillFood()
+	} else if g.Config.DetectLoops && !g.Snake.IsDead && g.Snake.recordState() {
+		g.Snake.Die(Looped)
+	}
+
+	if g.Config.MaxMoves > 0 && g.Moves >= g.Config.MaxMoves {
+		g.Snake.Die(MoveCapped)
+	}
+
+	if g.Snake.IsDead {
+		g.IsOver = true
 	}
+}
+
+func InBounds(x, y int) bool {
+	return x >= 0 && x < BoardWidth && y >= 0 && y < BoardHeight
+}
 
-	return nil
+// WrapLocation maps a location outside the board back onto it from the opposite edge
+func WrapLocation(x, y int) (int, int) {
+	return (x%BoardWidth + BoardWidth) % BoardWidth, (y%BoardHeight + BoardHeight) % BoardHeight
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
 	return BoardWidth * squareSize, BoardHeight * squareSize
 }
 
-func RunGame(game *Game) {
+func RunGame(runner *Runner) {
 	ebiten.SetWindowSize(640, 640*BoardHeight/BoardWidth)
 	ebiten.SetWindowTitle("Snake Game")
-	// ebiten.SetTPS(10) // Cannot set the TPS because inputs will get dropped
-	if err := ebiten.RunGame(game); err != nil {
+	// ebiten.SetTPS(10) // Not needed, the runner's clock paces the moves while inputs are polled every frame
+	if err := ebiten.RunGame(runner); err != nil {
 		panic(err)
 	}
 }
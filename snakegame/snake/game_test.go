@@ -0,0 +1,192 @@
+package snake
+
+import "testing"
+
+// scriptedInput turns the snake through Directions over and over
+type scriptedInput struct {
+	Directions []int
+	next       int
+}
+
+func (input *scriptedInput) Init() {}
+
+func (input *scriptedInput) Reset() {
+	input.next = 0
+}
+
+func (input *scriptedInput) Poll() {}
+
+func (input *scriptedInput) HandleInput(game *Game, snake *Snake) {
+	snake.TargetDirection = input.Directions[input.next%len(input.Directions)]
+	input.next++
+}
+
+// circling goes around a 3x3 square above and to the right of the default head, coming back to the same state every
+// 8 moves
+var circling = []int{UpDirection, UpDirection, RightDirection, RightDirection, DownDirection, DownDirection, LeftDirection, LeftDirection}
+
+// farFood keeps the only food in the corner, out of the way of the circling snake
+var farFood = &Level{FixedFood: []Location{{X: BoardWidth - 1, Y: BoardHeight - 1}}}
+
+// playOut steps the game until it is over or has made limit moves
+func playOut(game *Game, limit int) {
+	for i := 0; i < limit && !game.IsOver; i++ {
+		game.Step()
+	}
+}
+
+// newTestGame starts a game with the default snake, its head on (5, 5) facing right
+func newTestGame(config Config) *Game {
+	game := &Game{Config: config}
+	game.Seed(1)
+	game.Reset()
+
+	return game
+}
+
+func TestIsSafeDirection(t *testing.T) {
+	game := newTestGame(Config{})
+	if game.IsSafeDirection(&game.Snake, LeftDirection) {
+		t.Error("reversing into the tail is safe")
+	}
+	for _, direction := range []int{UpDirection, RightDirection, DownDirection} {
+		if !game.IsSafeDirection(&game.Snake, direction) {
+			t.Errorf("direction %d on an open board isn't safe", direction)
+		}
+	}
+
+	level := &Level{}
+	level.Walls[6+5*BoardWidth] = true
+	game = newTestGame(Config{Level: level})
+	if game.IsSafeDirection(&game.Snake, RightDirection) {
+		t.Error("moving into a level wall is safe")
+	}
+}
+
+func TestIsSafeDirectionAtTheEdge(t *testing.T) {
+	for _, wrap := range []bool{false, true} {
+		game := newTestGame(Config{Wrap: wrap})
+		game.Snake.Head = Location{X: BoardWidth - 1, Y: 5}
+		game.Snake.Tail = []Location{{X: BoardWidth - 2, Y: 5}, {X: BoardWidth - 3, Y: 5}}
+
+		if safe := game.IsSafeDirection(&game.Snake, RightDirection); safe != wrap {
+			t.Errorf("wrap %v: moving off the board is safe %v", wrap, safe)
+		}
+	}
+}
+
+func TestIsSafeDirectionIntoTails(t *testing.T) {
+	game := newTestGame(Config{})
+	// curled up so the end of the tail is to the left of the head
+	game.Snake.Head = Location{X: 5, Y: 5}
+	game.Snake.Tail = []Location{{X: 5, Y: 6}, {X: 4, Y: 6}, {X: 4, Y: 5}}
+	game.Snake.Direction = UpDirection
+	if game.IsSafeDirection(&game.Snake, LeftDirection) {
+		t.Error("moving into the end of its own tail is safe")
+	}
+
+	// another snake's tail ends to the right of the head and moves away unless it is growing
+	other := &Snake{Head: Location{X: 6, Y: 3}, Tail: []Location{{X: 6, Y: 4}, {X: 6, Y: 5}}, Direction: UpDirection}
+	game.Snakes = append(game.Snakes, other)
+	if !game.IsSafeDirection(&game.Snake, RightDirection) {
+		t.Error("moving into the end of another snake's tail isn't safe")
+	}
+	other.Growth = 1
+	if game.IsSafeDirection(&game.Snake, RightDirection) {
+		t.Error("moving into the end of a growing snake's tail is safe")
+	}
+
+	other.IsDead = true
+	if !game.IsSafeDirection(&game.Snake, RightDirection) {
+		t.Error("a dead snake still blocks the board")
+	}
+}
+
+func TestLoopDetection(t *testing.T) {
+	game := newTestGame(Config{DetectLoops: true, Level: farFood})
+	game.Input = &scriptedInput{Directions: circling}
+	playOut(game, 1000)
+
+	// from the third move the whole tail is on the circle, so that state comes back one lap later
+	if game.Snake.DeathCause != Looped || game.Moves != 3+8 {
+		t.Errorf("circling snake died of %s after %d moves, expected looped after 11", game.Snake.DeathCause, game.Moves)
+	}
+}
+
+func TestLoopDetectionForgetsStatesOnFood(t *testing.T) {
+	// food on the circle is eaten on the fourth move, the states before it don't count and the snake grows, so the
+	// first state seen again is the one right after eating
+	level := &Level{FixedFood: []Location{{X: 7, Y: 3}, {X: BoardWidth - 1, Y: BoardHeight - 1}}}
+	game := newTestGame(Config{DetectLoops: true, Level: level})
+	game.Input = &scriptedInput{Directions: circling}
+	playOut(game, 1000)
+
+	if game.Snake.DeathCause != Looped || game.Moves != 5+8 {
+		t.Errorf("snake died of %s after %d moves, expected looped after 13", game.Snake.DeathCause, game.Moves)
+	}
+}
+
+func TestMaxMoves(t *testing.T) {
+	game := newTestGame(Config{MaxMoves: 50, Level: farFood})
+	game.Input = &scriptedInput{Directions: circling}
+	playOut(game, 1000)
+
+	if game.Snake.DeathCause != MoveCapped || game.Moves != 50 {
+		t.Errorf("snake died of %s after %d moves, expected move capped after 50", game.Snake.DeathCause, game.Moves)
+	}
+}
+
+func TestDeathCauses(t *testing.T) {
+	tests := []struct {
+		name       string
+		directions []int
+		cause      DeathCause
+		moves      int
+	}{
+		// the head starts on row 5
+		{"wall", []int{UpDirection}, HitWall, 6},
+		// the head comes back down onto the end of the tail before it moves away
+		{"self", []int{UpDirection, LeftDirection, DownDirection}, HitSelf, 3},
+		// without loop detection circling goes on until the snake starves
+		{"starved", circling, Starved, MaxMovesSinceFood + 1},
+	}
+
+	for _, test := range tests {
+		game := newTestGame(Config{Level: farFood})
+		game.Input = &scriptedInput{Directions: test.directions}
+		playOut(game, 1000)
+
+		if !game.IsOver || game.Snake.DeathCause != test.cause || game.Moves != test.moves {
+			t.Errorf("%s: snake died of %s after %d moves, expected %s after %d", test.name, game.Snake.DeathCause, game.Moves, test.cause, test.moves)
+		}
+	}
+}
+
+func TestWrapLocation(t *testing.T) {
+	tests := []struct{ x, y, wrappedX, wrappedY int }{
+		{0, 0, 0, 0},
+		{BoardWidth - 1, BoardHeight - 1, BoardWidth - 1, BoardHeight - 1},
+		{-1, 3, BoardWidth - 1, 3},
+		{BoardWidth, 3, 0, 3},
+		{3, -1, 3, BoardHeight - 1},
+		{3, BoardHeight, 3, 0},
+		{-BoardWidth - 2, 2*BoardHeight + 1, BoardWidth - 2, 1},
+	}
+
+	for _, test := range tests {
+		if x, y := WrapLocation(test.x, test.y); x != test.wrappedX || y != test.wrappedY {
+			t.Errorf("(%d, %d) wrapped to (%d, %d), expected (%d, %d)", test.x, test.y, x, y, test.wrappedX, test.wrappedY)
+		}
+	}
+}
+
+func TestWrappedGameCrossesTheEdge(t *testing.T) {
+	// the head starts 6 squares from the top, so going up wraps on the sixth move and dies on the next without wrap
+	game := newTestGame(Config{Wrap: true, Level: farFood})
+	game.Input = &scriptedInput{Directions: []int{UpDirection}}
+	playOut(game, 6)
+
+	if game.IsOver || game.Snake.Head != (Location{X: 5, Y: BoardHeight - 1}) {
+		t.Errorf("snake at %v over %v after going up through the top edge", game.Snake.Head, game.IsOver)
+	}
+}
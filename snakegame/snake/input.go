@@ -1,5 +1,11 @@
 package snake
 
+// Input controls a snake, every controller goes through the same lifecycle:
+// Init once before it is first used, Reset whenever the game starts over,
+// Poll every frame so devices can be read between moves, and HandleInput once before every move.
 type Input interface {
+	Init()
+	Reset()
+	Poll()
 	HandleInput(game *Game, snake *Snake)
 }
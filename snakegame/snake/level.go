@@ -0,0 +1,113 @@
+package snake
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Level is a board layout with static walls, loaded from a text map where each line is a row of the board:
+//
+//	# wall
+//	. empty
+//	S snake start, the snake faces right with its tail to the left, without one it starts at the default start
+//	F fixed food, food is placed on these squares in order instead of randomly
+type Level struct {
+	Walls     [BoardWidth * BoardHeight]bool
+	Start     Location
+	HasStart  bool
+	FixedFood []Location
+}
+
+func LoadLevel(path string) (*Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := ParseLevel(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return level, nil
+}
+
+func ParseLevel(text string) (*Level, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	// ignore trailing blank lines
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) != BoardHeight {
+		return nil, fmt.Errorf("level has %d rows but the board is %d high", len(lines), BoardHeight)
+	}
+
+	level := &Level{}
+	for y, line := range lines {
+		if len(line) != BoardWidth {
+			return nil, fmt.Errorf("level row %d has %d columns but the board is %d wide", y+1, len(line), BoardWidth)
+		}
+
+		for x, square := range line {
+			switch square {
+			case '#':
+				level.Walls[x+y*BoardWidth] = true
+			case '.':
+			case 'S':
+				if level.HasStart {
+					return nil, fmt.Errorf("level has more than one start at row %d column %d", y+1, x+1)
+				}
+				level.Start = Location{X: x, Y: y}
+				level.HasStart = true
+			case 'F':
+				level.FixedFood = append(level.FixedFood, Location{X: x, Y: y})
+			default:
+				return nil, fmt.Errorf("unknown square %q at row %d column %d", square, y+1, x+1)
+			}
+		}
+	}
+
+	// make sure the starting snake fits, on the default start too when the level has none
+	start := level.startingSnake()
+	for _, location := range start {
+		if !InBounds(location.X, location.Y) || level.IsWall(location.X, location.Y) {
+			return nil, fmt.Errorf("snake starting at row %d column %d needs 3 empty squares to its left", start[0].Y+1, start[0].X+1)
+		}
+	}
+
+	return level, nil
+}
+
+func (level *Level) IsWall(x, y int) bool {
+	if !InBounds(x, y) {
+		return false
+	}
+
+	return level.Walls[x+y*BoardWidth]
+}
+
+// startingSnake returns the head and tail locations of a snake placed on the level's start, or on the default start
+// if it has none
+func (level *Level) startingSnake() []Location {
+	if !level.HasStart {
+		return snakeStartingAt(defaultStart)
+	}
+
+	return snakeStartingAt(level.Start)
+}
+
+// defaultStart is where the snake's head starts on boards without a level start
+var defaultStart = Location{X: 5, Y: 5}
+
+// snakeStartingAt returns the head and tail locations of a snake facing right with its head on start
+func snakeStartingAt(start Location) []Location {
+	return []Location{
+		start,
+		{X: start.X - 1, Y: start.Y},
+		{X: start.X - 2, Y: start.Y},
+		{X: start.X - 3, Y: start.Y},
+	}
+}
@@ -0,0 +1,91 @@
+package snake
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// levelText builds an empty level with the given squares set, keyed by their location
+func levelText(squares map[Location]byte) string {
+	rows := make([]string, BoardHeight)
+	for y := range rows {
+		row := []byte(strings.Repeat(".", BoardWidth))
+		for location, square := range squares {
+			if location.Y == y {
+				row[location.X] = square
+			}
+		}
+		rows[y] = string(row)
+	}
+
+	return strings.Join(rows, "\n") + "\n"
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel(levelText(map[Location]byte{
+		{X: 0, Y: 0}: '#',
+		{X: 6, Y: 2}: 'S',
+		{X: 9, Y: 9}: 'F',
+		{X: 8, Y: 9}: 'F',
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !level.IsWall(0, 0) || level.IsWall(1, 0) || level.IsWall(-1, 0) {
+		t.Error("walls weren't read from the level")
+	}
+	if !level.HasStart || level.Start != (Location{X: 6, Y: 2}) {
+		t.Errorf("start is %v, expected row 3 column 7", level.Start)
+	}
+	// fixed food is kept in reading order
+	if len(level.FixedFood) != 2 || level.FixedFood[0] != (Location{X: 8, Y: 9}) {
+		t.Errorf("fixed food is %v", level.FixedFood)
+	}
+}
+
+func TestParseLevelErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		error string
+	}{
+		{"bad square", levelText(map[Location]byte{{X: 4, Y: 1}: 'x'}), "unknown square"},
+		{"two starts", levelText(map[Location]byte{{X: 4, Y: 1}: 'S', {X: 7, Y: 8}: 'S'}), "more than one start"},
+		{"start on the edge", levelText(map[Location]byte{{X: 2, Y: 1}: 'S'}), "needs 3 empty squares"},
+		{"wall behind the start", levelText(map[Location]byte{{X: 6, Y: 1}: 'S', {X: 4, Y: 1}: '#'}), "needs 3 empty squares"},
+		// without a start the snake starts with its head on row 6 column 6
+		{"wall on the default start", levelText(map[Location]byte{{X: 3, Y: 5}: '#'}), "row 6 column 6"},
+		{"missing row", strings.Repeat(strings.Repeat(".", BoardWidth)+"\n", BoardHeight-1), "rows"},
+		{"short row", levelText(nil)[1:], "columns"},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseLevel(test.text); err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Errorf("%s: got error %v, expected one about %q", test.name, err, test.error)
+		}
+	}
+}
+
+func TestLevelsLoad(t *testing.T) {
+	paths, err := filepath.Glob("../../levels/*.txt")
+	if err != nil || len(paths) == 0 {
+		t.Fatal("no levels found", err)
+	}
+
+	for _, path := range paths {
+		level, err := LoadLevel(path)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		game := newTestGame(Config{Level: level})
+		for _, location := range game.Snake.locations() {
+			if level.IsWall(location.X, location.Y) {
+				t.Errorf("%s: snake starts on a wall at %v", path, location)
+			}
+		}
+	}
+}
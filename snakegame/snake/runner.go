@@ -0,0 +1,174 @@
+package snake
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Simulation is a game that advances exactly one move per Step, like Game and Arena
+type Simulation interface {
+	Reset()
+	// Poll lets the inputs read their devices, it is called every frame even when no move is made
+	Poll()
+	Step()
+	Over() bool
+	Draw(screen *ebiten.Image)
+	Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int)
+}
+
+// Clock paces a simulation by deciding how many moves are made each frame
+type Clock interface {
+	// StartFrame is called once at the start of every frame
+	StartFrame()
+	// Ready reports whether another move should be made this frame
+	Ready() bool
+}
+
+// RealTimeClock makes one move every Interval of wall time and never more than one a frame. When it falls behind it
+// doesn't catch up, the move after a late one is an Interval after it.
+type RealTimeClock struct {
+	Interval time.Duration
+	next     time.Time
+}
+
+func (clock *RealTimeClock) StartFrame() {}
+
+func (clock *RealTimeClock) Ready() bool {
+	now := time.Now()
+	if now.Before(clock.next) {
+		return false
+	}
+
+	// don't try to catch up on more than one interval, after a pause the game should carry on rather than rush
+	clock.next = clock.next.Add(clock.Interval)
+	if clock.next.Before(now) {
+		clock.next = now.Add(clock.Interval)
+	}
+
+	return true
+}
+
+// FixedRateClock makes StepsPerFrame moves every frame, so the game speed follows the frame rate
+type FixedRateClock struct {
+	StepsPerFrame int
+	remaining     int
+}
+
+func (clock *FixedRateClock) StartFrame() {
+	clock.remaining = clock.StepsPerFrame
+}
+
+func (clock *FixedRateClock) Ready() bool {
+	if clock.remaining <= 0 {
+		return false
+	}
+	clock.remaining--
+
+	return true
+}
+
+// FastClock makes moves as fast as they can be computed, for up to Budget each frame so drawing can keep up,
+// a Budget of 0 has no limit and runs until the game is over
+type FastClock struct {
+	Budget   time.Duration
+	deadline time.Time
+}
+
+func (clock *FastClock) StartFrame() {
+	clock.deadline = time.Now().Add(clock.Budget)
+}
+
+func (clock *FastClock) Ready() bool {
+	return clock.Budget == 0 || time.Now().Before(clock.deadline)
+}
+
+// ManualClock only moves when Key is pressed or Advance is called
+type ManualClock struct {
+	Key     ebiten.Key
+	pending int
+}
+
+func (clock *ManualClock) StartFrame() {
+	if inpututil.IsKeyJustPressed(clock.Key) {
+		clock.Advance()
+	}
+}
+
+func (clock *ManualClock) Advance() {
+	clock.pending++
+}
+
+func (clock *ManualClock) Ready() bool {
+	if clock.pending <= 0 {
+		return false
+	}
+	clock.pending--
+
+	return true
+}
+
+// Advance runs one frame of the simulations, polling their inputs and stepping them together for as long as the clock allows
+func Advance(clock Clock, simulations ...Simulation) {
+	for _, simulation := range simulations {
+		simulation.Poll()
+	}
+
+	clock.StartFrame()
+	for !allOver(simulations) && clock.Ready() {
+		for _, simulation := range simulations {
+			if !simulation.Over() {
+				simulation.Step()
+			}
+		}
+	}
+}
+
+func allOver(simulations []Simulation) bool {
+	for _, simulation := range simulations {
+		if !simulation.Over() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Runner plays a simulation at the pace of its clock, it implements ebiten.Game so it can be run directly.
+// Space restarts the game.
+type Runner struct {
+	Simulation Simulation
+	Clock      Clock
+	// restart the game as soon as it is over
+	AutoRestart bool
+}
+
+func NewRunner(simulation Simulation, clock Clock) *Runner {
+	return &Runner{Simulation: simulation, Clock: clock}
+}
+
+func (runner *Runner) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || (runner.AutoRestart && runner.Simulation.Over()) {
+		runner.Simulation.Reset()
+	}
+
+	Advance(runner.Clock, runner.Simulation)
+
+	return nil
+}
+
+// Run plays the simulation to the end without drawing, frame after frame of the clock
+func (runner *Runner) Run() {
+	for !runner.Simulation.Over() {
+		Advance(runner.Clock, runner.Simulation)
+	}
+}
+
+func (runner *Runner) Draw(screen *ebiten.Image) {
+	runner.Simulation.Draw(screen)
+}
+
+func (runner *Runner) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return runner.Simulation.Layout(outsideWidth, outsideHeight)
+}
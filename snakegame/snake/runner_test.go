@@ -0,0 +1,112 @@
+package snake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// countingSimulation counts its moves and is over after Moves of them, each taking StepTime
+type countingSimulation struct {
+	Moves    int
+	StepTime time.Duration
+	steps    int
+	polls    int
+}
+
+func (simulation *countingSimulation) Reset() {
+	simulation.steps = 0
+}
+
+func (simulation *countingSimulation) Poll() {
+	simulation.polls++
+}
+
+func (simulation *countingSimulation) Step() {
+	time.Sleep(simulation.StepTime)
+	simulation.steps++
+}
+
+func (simulation *countingSimulation) Over() bool {
+	return simulation.steps >= simulation.Moves
+}
+
+func (simulation *countingSimulation) Draw(screen *ebiten.Image) {}
+
+func (simulation *countingSimulation) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return outsideWidth, outsideHeight
+}
+
+func TestFastClockBudget(t *testing.T) {
+	// without a budget the whole game is played in one frame
+	simulation := &countingSimulation{Moves: 500}
+	Advance(&FastClock{}, simulation)
+	if simulation.steps != 500 {
+		t.Errorf("unlimited frame made %d moves, expected all 500", simulation.steps)
+	}
+
+	// 2ms moves fit 5 times in a 10ms budget, plus the one started just before it runs out
+	simulation = &countingSimulation{Moves: 500, StepTime: 2 * time.Millisecond}
+	Advance(&FastClock{Budget: 10 * time.Millisecond}, simulation)
+	if simulation.steps < 1 || simulation.steps > 6 {
+		t.Errorf("10ms frame made %d moves of 2ms", simulation.steps)
+	}
+}
+
+func TestManualClock(t *testing.T) {
+	simulation := &countingSimulation{Moves: 10}
+	clock := &ManualClock{Key: ebiten.KeyPeriod}
+
+	Advance(clock, simulation)
+	if simulation.steps != 0 || simulation.polls != 1 {
+		t.Errorf("made %d moves before advancing, polled %d times", simulation.steps, simulation.polls)
+	}
+
+	// advances are kept until frames use them up, one move each
+	clock.Advance()
+	clock.Advance()
+	Advance(clock, simulation)
+	if simulation.steps != 2 {
+		t.Errorf("made %d moves after advancing twice", simulation.steps)
+	}
+	Advance(clock, simulation)
+	if simulation.steps != 2 {
+		t.Errorf("made %d moves on a frame after the advances were used", simulation.steps)
+	}
+}
+
+func TestFixedRateClock(t *testing.T) {
+	simulation := &countingSimulation{Moves: 10}
+	clock := &FixedRateClock{StepsPerFrame: 3}
+
+	for _, expected := range []int{3, 6, 9, 10} {
+		Advance(clock, simulation)
+		if simulation.steps != expected {
+			t.Errorf("made %d moves, expected %d", simulation.steps, expected)
+		}
+	}
+}
+
+func TestRealTimeClockDoesntCatchUp(t *testing.T) {
+	simulation := &countingSimulation{Moves: 10}
+	clock := &RealTimeClock{Interval: time.Hour}
+
+	// the first move is made straight away, the next only an hour later
+	Advance(clock, simulation)
+	Advance(clock, simulation)
+	if simulation.steps != 1 {
+		t.Errorf("made %d moves in the first hour", simulation.steps)
+	}
+
+	// a day behind is still a single move, and the next one is an interval after it
+	clock.next = time.Now().Add(-24 * time.Hour)
+	Advance(clock, simulation)
+	Advance(clock, simulation)
+	if simulation.steps != 2 {
+		t.Errorf("made %d moves after falling a day behind, expected 1 more", simulation.steps)
+	}
+	if wait := time.Until(clock.next); wait < 59*time.Minute {
+		t.Errorf("next move is due in %s after catching up", wait)
+	}
+}
@@ -1,14 +1,61 @@
 package snake
 
+// MaxMovesSinceFood is how long a snake can go without eating before it starves
+const MaxMovesSinceFood = 100
+
+type DeathCause int
+
+const (
+	NotDead DeathCause = iota
+	HitWall
+	HitSelf
+	Starved
+	Looped
+	MoveCapped
+	HitSnake
+	HeadOn
+)
+
+func (cause DeathCause) String() string {
+	switch cause {
+	case NotDead:
+		return "alive"
+	case HitWall:
+		return "wall"
+	case HitSelf:
+		return "self"
+	case Starved:
+		return "starved"
+	case Looped:
+		return "loop"
+	case MoveCapped:
+		return "move cap"
+	case HitSnake:
+		return "other snake"
+	case HeadOn:
+		return "head on"
+	}
+
+	return "unknown"
+}
+
 type Snake struct {
-	AteFood         bool
 	IsDead          bool
+	DeathCause      DeathCause
 	Head            Location
 	Tail            []Location
 	Direction       int
 	TargetDirection int
 
 	MovesSinceFood int
+
+	// tail segments still to be added from food eaten
+	Growth int
+	// how much of each type of food the snake has eaten
+	Eaten [FoodTypeCount]int
+
+	// states seen since the last apple, used for loop detection
+	seenStates map[uint64]bool
 }
 
 func (snake *Snake) ContainsLocation(x, y int, checkHead bool) bool {
@@ -25,7 +72,23 @@ func (snake *Snake) ContainsLocation(x, y int, checkHead bool) bool {
 	return false
 }
 
+// locations returns the head followed by the tail
+func (snake *Snake) locations() []Location {
+	return append([]Location{snake.Head}, snake.Tail...)
+}
+
+// IsLegalDirection reports whether the snake is allowed to turn towards direction, reversing into itself is not allowed
+func (snake *Snake) IsLegalDirection(direction int) bool {
+	return direction >= UpDirection && direction <= LeftDirection && direction != (snake.Direction+2)%4
+}
+
 func (snake *Snake) Update() {
+	snake.advance()
+	snake.settle()
+}
+
+// advance moves the head one square in the target direction
+func (snake *Snake) advance() {
 	// Push head location to the tail
 	snake.Tail = append([]Location{snake.Head}, snake.Tail...)
 
@@ -36,33 +99,100 @@ func (snake *Snake) Update() {
 
 	// Move current head location based on direction
 	snake.Direction = snake.TargetDirection
-	switch snake.Direction {
+	snake.Head = snake.Head.moved(snake.Direction)
+}
+
+// moved returns the location one square away in direction
+func (location Location) moved(direction int) Location {
+	switch direction {
 	case UpDirection:
-		snake.Head.Y--
+		location.Y--
 	case RightDirection:
-		snake.Head.X++
+		location.X++
 	case DownDirection:
-		snake.Head.Y++
+		location.Y++
 	case LeftDirection:
-		snake.Head.X--
+		location.X--
 	}
 
+	return location
+}
+
+// settle resolves the move made by advance, the head must already be on its final square
+func (snake *Snake) settle() {
 	// Check if snake hit itself
 	if snake.ContainsLocation(snake.Head.X, snake.Head.Y, false) {
-		snake.IsDead = true
+		snake.Die(HitSelf)
 		return
 	}
 
-	// Pop last tail location if did not eat food
-	if !snake.AteFood {
+	// Pop last tail location if there is no growth left from food
+	if snake.Growth == 0 {
 		snake.Tail = snake.Tail[:len(snake.Tail)-1]
 		snake.MovesSinceFood++
 	} else {
 		snake.MovesSinceFood = 0
-		snake.AteFood = false
+		snake.Growth--
+	}
+
+	if snake.MovesSinceFood > MaxMovesSinceFood {
+		snake.Die(Starved)
+	}
+}
+
+// shrink removes segments from the end of the tail, always leaving at least one
+func (snake *Snake) shrink(segments int) {
+	for i := 0; i < segments && len(snake.Tail) > 1; i++ {
+		snake.Tail = snake.Tail[:len(snake.Tail)-1]
+	}
+}
+
+// Die kills the snake, keeping the first cause if it was already dead
+func (snake *Snake) Die(cause DeathCause) {
+	if snake.IsDead {
+		return
 	}
 
-	if snake.MovesSinceFood > 100 {
-		snake.IsDead = true
+	snake.IsDead = true
+	snake.DeathCause = cause
+}
+
+// stateHash hashes the head, direction and tail with FNV-1a
+func (snake *Snake) stateHash() uint64 {
+	hash := uint64(14695981039346656037)
+	mix := func(value int) {
+		hash ^= uint64(value)
+		hash *= 1099511628211
+	}
+
+	mix(snake.Head.X)
+	mix(snake.Head.Y)
+	mix(snake.Direction)
+	for _, tail := range snake.Tail {
+		mix(tail.X)
+		mix(tail.Y)
+	}
+
+	return hash
+}
+
+// recordState remembers the current state and reports whether it has already been seen since the last apple
+func (snake *Snake) recordState() bool {
+	if snake.seenStates == nil {
+		snake.seenStates = make(map[uint64]bool)
+	}
+
+	hash := snake.stateHash()
+	if snake.seenStates[hash] {
+		return true
+	}
+	snake.seenStates[hash] = true
+
+	return false
+}
+
+func (snake *Snake) forgetStates() {
+	for hash := range snake.seenStates {
+		delete(snake.seenStates, hash)
 	}
 }
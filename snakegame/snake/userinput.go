@@ -1,19 +1,129 @@
 package snake
 
-import "github.com/hajimehoshi/ebiten/v2"
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
 
+// maxQueuedTurns is how many presses are remembered between moves
+const maxQueuedTurns = 3
+
+// stickDeadZone is how far a gamepad stick has to be pushed before it counts as a press
+const stickDeadZone = 0.5
+
+// keys and buttons are checked in this order, so presses landing on the same frame always queue the same way
+var directionKeys = []struct {
+	key       ebiten.Key
+	direction int
+}{
+	{ebiten.KeyUp, UpDirection},
+	{ebiten.KeyRight, RightDirection},
+	{ebiten.KeyDown, DownDirection},
+	{ebiten.KeyLeft, LeftDirection},
+	{ebiten.KeyW, UpDirection},
+	{ebiten.KeyD, RightDirection},
+	{ebiten.KeyS, DownDirection},
+	{ebiten.KeyA, LeftDirection},
+}
+
+var directionButtons = []struct {
+	button    ebiten.StandardGamepadButton
+	direction int
+}{
+	{ebiten.StandardGamepadButtonLeftTop, UpDirection},
+	{ebiten.StandardGamepadButtonLeftRight, RightDirection},
+	{ebiten.StandardGamepadButtonLeftBottom, DownDirection},
+	{ebiten.StandardGamepadButtonLeftLeft, LeftDirection},
+}
+
+// UserInput steers the snake from the arrow keys, WASD or a gamepad's d-pad and left stick.
+// Presses are queued between moves so quick turns like up then left aren't dropped.
 type UserInput struct {
+	queue    []int
+	gamepads []ebiten.GamepadID
+	// direction each gamepad's stick was last pushed in, -1 when centered
+	stickDirections map[ebiten.GamepadID]int
+}
+
+func (input *UserInput) Init() {
+	input.Reset()
+}
+
+func (input *UserInput) Reset() {
+	input.queue = input.queue[:0]
+}
+
+func (input *UserInput) Poll() {
+	for _, binding := range directionKeys {
+		if inpututil.IsKeyJustPressed(binding.key) {
+			input.push(binding.direction)
+		}
+	}
+
+	input.gamepads = ebiten.AppendGamepadIDs(input.gamepads[:0])
+	for _, id := range input.gamepads {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+
+		for _, binding := range directionButtons {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, binding.button) {
+				input.push(binding.direction)
+			}
+		}
+
+		// the stick counts as a press when it moves into a new direction, the map is made here so a UserInput
+		// that was never given to Init still works
+		if input.stickDirections == nil {
+			input.stickDirections = make(map[ebiten.GamepadID]int)
+		}
+		direction := stickDirection(id)
+		if previous, ok := input.stickDirections[id]; (!ok || previous != direction) && direction != -1 {
+			input.push(direction)
+		}
+		input.stickDirections[id] = direction
+	}
+}
+
+func stickDirection(id ebiten.GamepadID) int {
+	x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	y := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+
+	if x*x+y*y < stickDeadZone*stickDeadZone {
+		return -1
+	}
+
+	if x > y && x > -y {
+		return RightDirection
+	} else if x < y && x < -y {
+		return LeftDirection
+	} else if y > 0 {
+		return DownDirection
+	}
+
+	return UpDirection
+}
+
+func (input *UserInput) push(direction int) {
+	// pressing the same direction twice in a row is a single turn
+	if len(input.queue) > 0 && input.queue[len(input.queue)-1] == direction {
+		return
+	}
+
+	if len(input.queue) < maxQueuedTurns {
+		input.queue = append(input.queue, direction)
+	}
 }
 
 func (input *UserInput) HandleInput(game *Game, snake *Snake) {
-	// Handle updating head direction based on key input
-	if ebiten.IsKeyPressed(ebiten.KeyUp) && snake.Direction != DownDirection {
-		snake.TargetDirection = UpDirection
-	} else if ebiten.IsKeyPressed(ebiten.KeyRight) && snake.Direction != LeftDirection {
-		snake.TargetDirection = RightDirection
-	} else if ebiten.IsKeyPressed(ebiten.KeyDown) && snake.Direction != UpDirection {
-		snake.TargetDirection = DownDirection
-	} else if ebiten.IsKeyPressed(ebiten.KeyLeft) && snake.Direction != RightDirection {
-		snake.TargetDirection = LeftDirection
+	// Take the oldest queued turn the snake can make, skipping ones that would reverse it or not change anything
+	for len(input.queue) > 0 {
+		direction := input.queue[0]
+		input.queue = input.queue[1:]
+
+		if snake.IsLegalDirection(direction) && direction != snake.Direction {
+			snake.TargetDirection = direction
+			return
+		}
 	}
 }
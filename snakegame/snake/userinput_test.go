@@ -0,0 +1,65 @@
+package snake
+
+import (
+	"reflect"
+	"testing"
+)
+
+// turns plays the queued turns on the default snake, returning the direction it took on each move
+func turns(input *UserInput, moves int) []int {
+	game := newTestGame(Config{Level: farFood})
+	game.Input = input
+
+	directions := make([]int, 0, moves)
+	for i := 0; i < moves; i++ {
+		game.Step()
+		directions = append(directions, game.Snake.Direction)
+	}
+
+	return directions
+}
+
+func TestUserInputQueuesQuickTurns(t *testing.T) {
+	// up then left pressed between two moves are both taken, one move each
+	input := &UserInput{}
+	input.push(UpDirection)
+	input.push(LeftDirection)
+
+	if directions := turns(input, 3); !reflect.DeepEqual(directions, []int{UpDirection, LeftDirection, LeftDirection}) {
+		t.Errorf("snake went %v, expected up then left", directions)
+	}
+}
+
+func TestUserInputSkipsUselessTurns(t *testing.T) {
+	// reversing and going the way the snake already goes are dropped without using up a move
+	input := &UserInput{}
+	input.push(LeftDirection)
+	input.push(RightDirection)
+	input.push(DownDirection)
+
+	if directions := turns(input, 2); !reflect.DeepEqual(directions, []int{DownDirection, DownDirection}) {
+		t.Errorf("snake went %v, expected down straight away", directions)
+	}
+}
+
+func TestUserInputQueueLimit(t *testing.T) {
+	input := &UserInput{}
+	// the same direction twice in a row is one press
+	input.push(UpDirection)
+	input.push(UpDirection)
+	if len(input.queue) != 1 {
+		t.Errorf("queue is %v after pressing up twice", input.queue)
+	}
+
+	for _, direction := range []int{LeftDirection, UpDirection, RightDirection, DownDirection} {
+		input.push(direction)
+	}
+	if !reflect.DeepEqual(input.queue, []int{UpDirection, LeftDirection, UpDirection}) {
+		t.Errorf("queue is %v, expected the first %d presses", input.queue, maxQueuedTurns)
+	}
+
+	input.Reset()
+	if len(input.queue) != 0 {
+		t.Errorf("queue is %v after a reset", input.queue)
+	}
+}